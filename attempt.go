@@ -0,0 +1,17 @@
+package easyhttp
+
+import "context"
+
+type attemptKey struct{}
+
+// ContextWithAttempt returns a copy of ctx carrying the attempt number of a request,
+// 0 is the first attempt. It is set by the retry interceptor.
+func ContextWithAttempt(ctx context.Context, attempt uint) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// AttemptFromContext returns the attempt number carried by ctx, false if the request is not retried.
+func AttemptFromContext(ctx context.Context) (uint, bool) {
+	attempt, ok := ctx.Value(attemptKey{}).(uint)
+	return attempt, ok
+}
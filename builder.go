@@ -0,0 +1,189 @@
+package easyhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/soyacen/easyhttp/internal/bodyutils"
+	"github.com/soyacen/easyhttp/internal/headerutils"
+	"github.com/soyacen/easyhttp/internal/urlutils"
+)
+
+const kJsonContentType = "application/json"
+
+// RequestBuilder composes a request top-to-bottom and executes it with Do.
+// Every method returns a new RequestBuilder and leaves the receiver untouched,
+// so a builder can be used as a template across calls.
+type RequestBuilder struct {
+	cli          *Client
+	method       string
+	url          string
+	interceptors []Interceptor
+	into         interface{}
+}
+
+// R returns a new RequestBuilder bound to cli, the default method is GET.
+func (cli *Client) R() *RequestBuilder {
+	return &RequestBuilder{cli: cli, method: http.MethodGet}
+}
+
+func (b *RequestBuilder) clone() *RequestBuilder {
+	nb := *b
+	nb.interceptors = append(make([]Interceptor, 0, len(b.interceptors)+1), b.interceptors...)
+	return &nb
+}
+
+func (b *RequestBuilder) with(itcptrs ...Interceptor) *RequestBuilder {
+	nb := b.clone()
+	nb.interceptors = append(nb.interceptors, itcptrs...)
+	return nb
+}
+
+// Method sets the HTTP method.
+func (b *RequestBuilder) Method(method string) *RequestBuilder {
+	nb := b.clone()
+	nb.method = method
+	return nb
+}
+
+// URL sets the request url, it may contain path param expressions like /users/:id.
+func (b *RequestBuilder) URL(url string) *RequestBuilder {
+	nb := b.clone()
+	nb.url = url
+	return nb
+}
+
+// Use appends interceptors to the request, they run after the ones added before.
+func (b *RequestBuilder) Use(itcptrs ...Interceptor) *RequestBuilder {
+	return b.with(itcptrs...)
+}
+
+// Header sets the header entries associated with key to the single element value.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		headerutils.Set(req.RawRequest(), key, value)
+		return do(cli, req)
+	})
+}
+
+// Query adds the values to key in the url query.
+func (b *RequestBuilder) Query(key string, values ...string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		urlutils.AddQueryParam(req.RawRequest().URL, key, values...)
+		return do(cli, req)
+	})
+}
+
+// PathParam replaces one or multiple path param expressions by the given value.
+// The route of the request is the url given to the builder, see Client.Execute.
+func (b *RequestBuilder) PathParam(key, value string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		rawRequest := req.RawRequest()
+		rawRequest.URL.Path = urlutils.ReplacePathParam(rawRequest.URL.Path, key, value)
+		return do(cli, req)
+	})
+}
+
+// BearerToken sets the Authorization header with the bearer token.
+func (b *RequestBuilder) BearerToken(token string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		headerutils.SetAuthorization(req.RawRequest(), "Bearer", token)
+		return do(cli, req)
+	})
+}
+
+// BasicAuth sets the Authorization header to use HTTP Basic Authentication.
+func (b *RequestBuilder) BasicAuth(username, password string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		headerutils.SetBasicAuth(req.RawRequest(), username, password)
+		return do(cli, req)
+	})
+}
+
+// Body sets data as the request body with the given content type.
+func (b *RequestBuilder) Body(data []byte, contentType string) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		bodyutils.SetBytes(req.RawRequest(), data, contentType)
+		return do(cli, req)
+	})
+}
+
+// JSONBody marshals obj to JSON and sets it as the request body.
+// string and []byte are sent as they are.
+func (b *RequestBuilder) JSONBody(obj interface{}) *RequestBuilder {
+	return b.with(func(cli *Client, req *Request, do Doer) (reply *Reply, err error) {
+		data, err := bodyutils.Marshal(obj, json.Marshal)
+		if err != nil {
+			return nil, err
+		}
+		bodyutils.SetBytes(req.RawRequest(), data, kJsonContentType)
+		return do(cli, req)
+	})
+}
+
+// Into decodes the response body into v after Do, according to the response Content-Type.
+// XML and protobuf are recognized, anything else is decoded as JSON.
+func (b *RequestBuilder) Into(v interface{}) *RequestBuilder {
+	nb := b.clone()
+	nb.into = v
+	return nb
+}
+
+// Do executes the request.
+func (b *RequestBuilder) Do(ctx context.Context) (*Reply, error) {
+	reply, err := b.cli.Execute(ctx, b.method, b.url, b.interceptors...)
+	if err != nil {
+		return reply, err
+	}
+	if b.into != nil && reply != nil {
+		if err := decodeInto(reply, b.into); err != nil {
+			return reply, err
+		}
+	}
+	return reply, nil
+}
+
+func decodeInto(reply *Reply, v interface{}) error {
+	var ct string
+	if reply.RawResponse() != nil {
+		ct = reply.RawResponse().Header.Get("Content-Type")
+	}
+	switch {
+	case strings.Contains(ct, "xml"):
+		return reply.DecodeXML(v)
+	case strings.Contains(ct, "protobuf"):
+		if m, ok := v.(proto.Message); ok {
+			return reply.DecodeProto(m)
+		}
+	}
+	return reply.DecodeJSON(v)
+}
+
+// Get executes a GET request to url.
+func (b *RequestBuilder) Get(ctx context.Context, url string) (*Reply, error) {
+	return b.Method(http.MethodGet).URL(url).Do(ctx)
+}
+
+// Post executes a POST request to url.
+func (b *RequestBuilder) Post(ctx context.Context, url string) (*Reply, error) {
+	return b.Method(http.MethodPost).URL(url).Do(ctx)
+}
+
+// Put executes a PUT request to url.
+func (b *RequestBuilder) Put(ctx context.Context, url string) (*Reply, error) {
+	return b.Method(http.MethodPut).URL(url).Do(ctx)
+}
+
+// Patch executes a PATCH request to url.
+func (b *RequestBuilder) Patch(ctx context.Context, url string) (*Reply, error) {
+	return b.Method(http.MethodPatch).URL(url).Do(ctx)
+}
+
+// Delete executes a DELETE request to url.
+func (b *RequestBuilder) Delete(ctx context.Context, url string) (*Reply, error) {
+	return b.Method(http.MethodDelete).URL(url).Do(ctx)
+}
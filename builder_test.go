@@ -0,0 +1,70 @@
+package easyhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"path":   r.URL.Path,
+			"query":  r.URL.Query().Get("q"),
+			"header": r.Header.Get("X-Token"),
+			"body":   body["name"],
+		})
+	}))
+	defer server.Close()
+
+	template := NewClient().R().Header("X-Token", "abc")
+	for _, id := range []string{"1", "2"} {
+		var out map[string]string
+		_, err := template.
+			Method(http.MethodPost).
+			URL(server.URL+"/users/:id").
+			PathParam("id", id).
+			Query("q", "easy").
+			JSONBody(map[string]string{"name": "http"}).
+			Into(&out).
+			Do(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{"path": "/users/" + id, "query": "easy", "header": "abc", "body": "http"}
+		for k, v := range expected {
+			if out[k] != v {
+				t.Fatalf("unexpected %s, expected %q, got %q", k, v, out[k])
+			}
+		}
+	}
+	if len(template.interceptors) != 1 {
+		t.Fatalf("template was modified, %d interceptors", len(template.interceptors))
+	}
+}
+
+func TestRequestBuilderRoute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var route, path string
+	_, err := NewClient().R().
+		PathParam("org", "acme").
+		PathParam("id", "42").
+		Use(func(cli *Client, req *Request, do Doer) (*Reply, error) {
+			route, path = req.Route(), req.RawRequest().URL.Path
+			return do(cli, req)
+		}).
+		Get(context.Background(), server.URL+"/orgs/:org/users/:id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != "/orgs/:org/users/:id" || path != "/orgs/acme/users/42" {
+		t.Fatalf("unexpected route %q and path %q", route, path)
+	}
+}
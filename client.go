@@ -8,13 +8,23 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/net/publicsuffix"
+
+	"github.com/soyacen/easyhttp/internal/urlutils"
 )
 
 type clientOptions struct {
 	interceptors []Interceptor
+	// err is an invalid option, it is returned by every request
+	err error
+
+	baseURL        *url.URL
+	defaultHeaders http.Header
+	defaultQuery   url.Values
+	isErrorStatus  func(statusCode int) bool
 
 	//  http.Client field
 	transport           http.RoundTripper
@@ -51,8 +61,8 @@ func (o *clientOptions) apply(opts ...ClientOption) {
 		opt(o)
 	}
 	if o.transport == nil {
-		transport := http.DefaultTransport.(*http.Transport)
-		copy := *transport
+		// a clone, copying the transport would share its connection pool and locks
+		copy := http.DefaultTransport.(*http.Transport).Clone()
 		if o.tlsConfig != nil {
 			copy.TLSClientConfig = o.tlsConfig
 		}
@@ -82,12 +92,122 @@ func (o *clientOptions) apply(opts ...ClientOption) {
 		if o.readBufferSize != nil {
 			copy.ReadBufferSize = *o.readBufferSize
 		}
-		o.transport = &copy
+		o.transport = copy
+	}
+}
+
+// resolveURL resolves rawURL against the base url, see WithBaseURL.
+func (o *clientOptions) resolveURL(rawURL string) string {
+	if o.baseURL == nil {
+		return rawURL
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.IsAbs() {
+		return rawURL
+	}
+	if strings.HasPrefix(rawURL, "//") {
+		return o.baseURL.Scheme + ":" + rawURL
+	}
+
+	ref, fragment := rawURL, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		ref, fragment = ref[:i], ref[i:]
+	}
+	path, query := ref, ""
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+
+	base := url.URL{Scheme: o.baseURL.Scheme, User: o.baseURL.User, Host: o.baseURL.Host}
+	resolved := base.String() + o.baseURL.EscapedPath()
+	if path = strings.TrimLeft(path, "/"); path != "" {
+		resolved = strings.TrimRight(resolved, "/") + "/" + path
+	}
+	if query != "" && o.baseURL.RawQuery != "" {
+		query = o.baseURL.RawQuery + "&" + query
+	} else if query == "" {
+		query = o.baseURL.RawQuery
+	}
+	if query != "" {
+		resolved += "?" + query
+	}
+	return resolved + fragment
+}
+
+// setDefaults sets the default headers and query parameters missing in rawReq.
+// It is the innermost step before the transport, so the values set by the interceptors win.
+func (o *clientOptions) setDefaults(rawReq *http.Request) {
+	if len(o.defaultHeaders) > 0 && rawReq.Header == nil {
+		rawReq.Header = make(http.Header)
+	}
+	for key, values := range o.defaultHeaders {
+		if _, ok := rawReq.Header[key]; !ok {
+			rawReq.Header[key] = append([]string(nil), values...)
+		}
+	}
+	if len(o.defaultQuery) > 0 {
+		query := rawReq.URL.Query()
+		missing := make(url.Values)
+		for key, values := range o.defaultQuery {
+			if _, ok := query[key]; !ok {
+				missing[key] = values
+			}
+		}
+		urlutils.AddQuery(rawReq.URL, missing)
 	}
 }
 
 type ClientOption func(o *clientOptions)
 
+// WithBaseURL binds the client to baseURL, relative urls passed to Execute are resolved against it.
+//
+// An absolute url (with a scheme) is used as it is, a scheme-relative url (//host/path) takes the scheme of baseURL.
+// Otherwise the path of the url is appended to the path of baseURL, whether or not it starts with a slash,
+// the query of baseURL is prepended to the query of the url and the fragment of the url is kept.
+// For example, with base url "http://api.example.com/v1?lang=en":
+//
+//	"users/:id"        -> "http://api.example.com/v1/users/:id?lang=en"
+//	"/users?page=2"    -> "http://api.example.com/v1/users?lang=en&page=2"
+//	""                 -> "http://api.example.com/v1?lang=en"
+//	"https://other/x"  -> "https://other/x"
+//
+// An invalid baseURL makes every request of the client fail with the parse error.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(o *clientOptions) {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			o.err = err
+			return
+		}
+		o.baseURL = u
+	}
+}
+
+// WithDefaultHeaders sets headers sent with every request, unless the request already has them
+// once every interceptor has run.
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(o *clientOptions) {
+		if o.defaultHeaders == nil {
+			o.defaultHeaders = make(http.Header)
+		}
+		for key, value := range headers {
+			o.defaultHeaders.Set(key, value)
+		}
+	}
+}
+
+// WithDefaultQuery sets query parameters sent with every request, unless the request url already has them
+// once every interceptor has run.
+func WithDefaultQuery(query map[string][]string) ClientOption {
+	return func(o *clientOptions) {
+		if o.defaultQuery == nil {
+			o.defaultQuery = make(url.Values)
+		}
+		for key, values := range query {
+			o.defaultQuery[key] = append(o.defaultQuery[key], values...)
+		}
+	}
+}
+
 func WithChainInterceptor(interceptors ...Interceptor) ClientOption {
 	return func(o *clientOptions) {
 		o.interceptors = append(o.interceptors, interceptors...)
@@ -285,6 +405,9 @@ func (cli *Client) Trace(ctx context.Context, url string, itcptrs ...Interceptor
 }
 
 func (cli *Client) Execute(ctx context.Context, method string, url string, itcptrs ...Interceptor) (reply *Reply, err error) {
+	if cli.opts.err != nil {
+		return nil, cli.opts.err
+	}
 	options := defaultExecuteOptions()
 	var execOpts []ExecuteOption
 	if len(itcptrs) > 0 {
@@ -293,11 +416,16 @@ func (cli *Client) Execute(ctx context.Context, method string, url string, itcpt
 	options.apply(execOpts...)
 	request := &Request{opts: options}
 	var rawReq *http.Request
-	rawReq, err = http.NewRequestWithContext(ctx, method, url, nil)
+	rawReq, err = http.NewRequestWithContext(ctx, method, cli.opts.resolveURL(url), nil)
 	if err != nil {
 		return nil, err
 	}
 	request.rawRequest = rawReq
+	// the route is known before the interceptors run, so the outer ones can group requests by route
+	// before the path params are replaced
+	if urlutils.HasPathParams(rawReq.URL.Path) {
+		request.SetRoute(rawReq.URL.Path)
+	}
 
 	allitcptrs := make([]Interceptor, 0, len(cli.opts.interceptors)+len(request.opts.interceptors))
 	for _, itcptr := range cli.opts.interceptors {
@@ -319,6 +447,15 @@ func (cli *Client) execute(req *Request) (resp *Reply, err error) {
 }
 
 func do(cli *Client, req *Request) (reply *Reply, err error) {
+	cli.opts.setDefaults(req.rawRequest)
+	if len(req.innermost) > 0 {
+		return chainInterceptors(req.innermost...)(cli, req, send)
+	}
+	return send(cli, req)
+}
+
+// send sends the final request, the innermost step of the chain.
+func send(cli *Client, req *Request) (reply *Reply, err error) {
 	rawResp, err := cli.rawClient.Do(req.rawRequest)
 	if err != nil {
 		return nil, err
@@ -327,5 +464,8 @@ func do(cli *Client, req *Request) (reply *Reply, err error) {
 		rawRequest:  req.rawRequest,
 		rawResponse: rawResp,
 	}
+	if isError := cli.opts.isErrorStatus; isError != nil && isError(rawResp.StatusCode) {
+		return reply, NewStatusError(reply, DefaultMaxErrorBodySize)
+	}
 	return reply, nil
 }
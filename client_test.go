@@ -0,0 +1,147 @@
+package easyhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/soyacen/easyhttp/internal/urlutils"
+)
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base     string
+		ref      string
+		expected string
+	}{
+		{"http://api.example.com/v1?lang=en", "users/:id", "http://api.example.com/v1/users/:id?lang=en"},
+		{"http://api.example.com/v1/", "/users?page=2", "http://api.example.com/v1/users?page=2"},
+		{"http://api.example.com/v1?lang=en", "/users?page=2#top", "http://api.example.com/v1/users?lang=en&page=2#top"},
+		{"http://api.example.com/v1?lang=en", "", "http://api.example.com/v1?lang=en"},
+		{"http://api.example.com", "users", "http://api.example.com/users"},
+		{"http://api.example.com/v1", "https://other.example.com/x", "https://other.example.com/x"},
+		{"https://api.example.com/v1", "//other.example.com/x", "https://other.example.com/x"},
+	}
+	for _, test := range tests {
+		o := defaultClientOptions()
+		WithBaseURL(test.base)(o)
+		if actual := o.resolveURL(test.ref); actual != test.expected {
+			t.Errorf("resolve %q against %q, expected %q, got %q", test.ref, test.base, test.expected, actual)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Token", r.Header.Get("X-Token"))
+		w.Header().Set("X-Lang", r.Header.Get("X-Lang"))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL+"/api"),
+		WithDefaultHeaders(map[string]string{"X-Token": "abc", "X-Lang": "en"}),
+		WithDefaultQuery(map[string][]string{"lang": {"en"}, "page": {"1"}, "size": {"10"}}),
+	)
+	reply, err := client.Get(context.Background(), "users?page=2",
+		func(cli *Client, req *Request, do Doer) (*Reply, error) {
+			// set by an interceptor, the default must not be added
+			urlutils.AddQuery(req.RawRequest().URL, url.Values{"size": {"20"}})
+			req.RawRequest().Header.Set("X-Lang", "fr")
+			return do(cli, req)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := reply.RawResponse().Header
+	if header.Get("X-Path") != "/api/users" {
+		t.Errorf("unexpected path %q", header.Get("X-Path"))
+	}
+	if header.Get("X-Query") != "page=2&size=20&lang=en" {
+		t.Errorf("unexpected query %q", header.Get("X-Query"))
+	}
+	if header.Get("X-Token") != "abc" {
+		t.Errorf("unexpected token %q", header.Get("X-Token"))
+	}
+	if header.Get("X-Lang") != "fr" {
+		t.Errorf("unexpected lang %q", header.Get("X-Lang"))
+	}
+}
+
+func TestInvalidBaseURL(t *testing.T) {
+	client := NewClient(WithBaseURL("http://[::1"))
+	if _, err := client.Get(context.Background(), "users"); err == nil {
+		t.Fatal("expected the parse error of the base url")
+	}
+}
+
+func TestRouteKeptBySetRawRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var route, contextRoute string
+	_, err := NewClient().Get(context.Background(), server.URL+"/health",
+		func(cli *Client, req *Request, do Doer) (*Reply, error) {
+			reply, err := do(cli, req)
+			route = req.Route()
+			contextRoute, _ = RouteFromContext(req.RawRequest().Context())
+			return reply, err
+		},
+		func(cli *Client, req *Request, do Doer) (*Reply, error) {
+			// like the balancer, the raw request is put back after the call
+			rawRequest := req.RawRequest()
+			req.SetRawRequest(rawRequest.WithContext(rawRequest.Context()))
+			defer req.SetRawRequest(rawRequest)
+			return do(cli, req)
+		},
+		func(cli *Client, req *Request, do Doer) (*Reply, error) {
+			req.SetRoute("/health")
+			return do(cli, req)
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != "/health" || contextRoute != "/health" {
+		t.Fatalf("the route is lost, got %q and %q", route, contextRoute)
+	}
+}
+
+func TestRouteOf(t *testing.T) {
+	rawRequest := httptest.NewRequest(http.MethodGet, "http://example.com/users/1", nil)
+	if route := RouteOf(rawRequest); route != "" {
+		t.Fatalf("got route %q without a route template", route)
+	}
+	if key := RouteKey(rawRequest); key != "GET example.com/users/1" {
+		t.Fatalf("got key %q without a route template", key)
+	}
+	rawRequest = rawRequest.WithContext(ContextWithRoute(rawRequest.Context(), "/users/:id"))
+	if route := RouteOf(rawRequest); route != "/users/:id" {
+		t.Fatalf("got route %q", route)
+	}
+	if key := RouteKey(rawRequest); key != "GET example.com/users/:id" {
+		t.Fatalf("got key %q", key)
+	}
+}
+
+func TestInnermost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var path, auth string
+	client := NewClient(WithChainInterceptor(Innermost(func(cli *Client, req *Request, do Doer) (*Reply, error) {
+		path, auth = req.RawRequest().URL.Path, req.RawRequest().Header.Get("Authorization")
+		return do(cli, req)
+	})))
+	_, err := client.R().PathParam("id", "1").BearerToken("token").Get(context.Background(), server.URL+"/users/:id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/users/1" || auth != "Bearer token" {
+		t.Fatalf("expected the final request, got %q and %q", path, auth)
+	}
+}
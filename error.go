@@ -0,0 +1,102 @@
+package easyhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultMaxErrorBodySize is the default number of bytes of the response body kept by a StatusError.
+const DefaultMaxErrorBodySize = 64 << 10
+
+// StatusError reports a response whose status code is not wanted, see WithErrorOnStatus.
+// It is returned together with the Reply, so errors.As can be used to get the details.
+type StatusError struct {
+	// StatusCode is the status code of the response, e.g. 404
+	StatusCode int
+	// Status is the status line of the response, e.g. "404 Not Found"
+	Status string
+	// Header is the header of the response
+	Header http.Header
+	// Body is the beginning of the response body, bounded by the max body size
+	Body []byte
+	// Payload is the decoded error body, it is only set by the ErrorInto option of the status interceptor,
+	// never by WithErrorOnStatus
+	Payload interface{}
+	// PayloadErr is the error reading or decoding the body into Payload
+	PayloadErr error
+
+	reply *Reply
+}
+
+// NewStatusError peeks at most maxBodySize bytes of the reply body and returns a StatusError describing the reply,
+// a negative maxBodySize keeps the whole body.
+// The body is not consumed, the whole of it stays available through reply.Bytes.
+func NewStatusError(reply *Reply, maxBodySize int64) *StatusError {
+	rawResponse := reply.RawResponse()
+	body := reply.peekBody(maxBodySize)
+	return &StatusError{
+		StatusCode: rawResponse.StatusCode,
+		Status:     rawResponse.Status,
+		Header:     rawResponse.Header,
+		Body:       body,
+		reply:      reply,
+	}
+}
+
+func (e *StatusError) Error() string {
+	const maxLen = 128
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("easyhttp: unexpected status %s", status)
+	}
+	snippet := e.Body
+	if len(snippet) > maxLen {
+		snippet = snippet[:maxLen]
+	}
+	return fmt.Sprintf("easyhttp: unexpected status %s: %s", status, snippet)
+}
+
+// Reply returns the reply the error was created from.
+func (e *StatusError) Reply() *Reply {
+	return e.reply
+}
+
+// IsFailure reports whether the outcome of a call is a failure of the server:
+// an error, a 5xx reply or a StatusError of a 5xx reply, or no reply at all.
+// A 4xx status is a failure of the client and a canceled call is given up by the client, they are not failures.
+// It is the default classifier of the breaker and of the balancer health picker.
+func IsFailure(reply *Reply, err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return reply == nil || reply.RawResponse() == nil || reply.RawResponse().StatusCode >= http.StatusInternalServerError
+}
+
+// IsErrorStatus is the default status check of WithErrorOnStatus, any status code >= 400 is an error.
+func IsErrorStatus(statusCode int) bool {
+	return statusCode >= http.StatusBadRequest
+}
+
+// WithErrorOnStatus makes the client return a *StatusError with the reply
+// when isError reports true for the response status code. If isError is nil, IsErrorStatus is used.
+//
+// The status is checked right after the transport, inside every client and per-call interceptor,
+// so that retry and breaker interceptors see the StatusError.
+// The StatusError carries no Payload, use the status interceptor with ErrorInto to decode the error body.
+func WithErrorOnStatus(isError func(statusCode int) bool) ClientOption {
+	if isError == nil {
+		isError = IsErrorStatus
+	}
+	return func(o *clientOptions) {
+		o.isErrorStatus = isError
+	}
+}
@@ -0,0 +1,17 @@
+package easyhttp
+
+import "net/http"
+
+// IdempotencyKeyHeader is the header carrying the idempotency key of a request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IsIdempotent reports whether the request can be sent several times without side effects:
+// its method is idempotent (GET, HEAD, OPTIONS, TRACE, PUT, DELETE) or it carries an Idempotency-Key.
+// It is the default policy of the retry and hedge interceptors.
+func IsIdempotent(rawRequest *http.Request) bool {
+	switch rawRequest.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return rawRequest.Header.Get(IdempotencyKeyHeader) != ""
+}
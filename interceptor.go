@@ -24,3 +24,16 @@ func getChainUnaryInvoker(interceptors []Interceptor, curr int, finalInvoker Doe
 		return interceptors[curr+1](httpclient, req, getChainUnaryInvoker(interceptors, curr+1, finalInvoker))
 	}
 }
+
+// Innermost returns an interceptor installing itcptr after every client and per-call interceptor,
+// right before the request is sent. itcptr sees the final request: its url with the path params replaced,
+// its query, headers and body, and the client defaults.
+// It is meant for the interceptors keyed by the request, e.g. a cache.
+func Innermost(itcptr Interceptor) Interceptor {
+	return func(cli *Client, req *Request, do Doer) (*Reply, error) {
+		outer := req.innermost
+		req.innermost = append(outer[:len(outer):len(outer)], itcptr)
+		defer func() { req.innermost = outer }()
+		return do(cli, req)
+	}
+}
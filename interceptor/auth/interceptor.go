@@ -1,21 +1,14 @@
 package easyhttpauth
 
 import (
-	"net/http"
-	"net/url"
-
-	"github.com/soyacen/goutils/stringutils"
-
 	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/headerutils"
+	"github.com/soyacen/easyhttp/internal/urlutils"
 )
 
 func BasicAuth(username, password string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		rawRequest := req.RawRequest()
-		if rawRequest.Header == nil {
-			rawRequest.Header = make(http.Header)
-		}
-		rawRequest.SetBasicAuth(username, password)
+		headerutils.SetBasicAuth(req.RawRequest(), username, password)
 		return do(cli, req)
 	}
 }
@@ -31,32 +24,21 @@ func APIKey(key string, value string, addTo APIKeyAddTo) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
 		rawRequest := req.RawRequest()
 		if addTo == AddToHeader {
-			if rawRequest.Header == nil {
-				rawRequest.Header = make(http.Header)
-			}
-			rawRequest.Header.Set(key, value)
+			headerutils.Set(rawRequest, key, value)
 		} else if addTo == AddToQuery {
-			query := make(url.Values)
-			query.Set(key, value)
-			reqURL := rawRequest.URL
-			if stringutils.IsBlank(reqURL.RawQuery) {
-				reqURL.RawQuery = query.Encode()
-				reqURL.Query()
-			} else {
-				reqURL.RawQuery = reqURL.RawQuery + "&" + query.Encode()
-			}
+			urlutils.AddQueryParam(rawRequest.URL, key, value)
 		}
 		return do(cli, req)
 	}
 }
 
 func BearerToken(token string) easyhttp.Interceptor {
-	return APIKey("Authorization", "Bearer "+token, AddToHeader)
+	return CustomToken("Bearer", token)
 }
 
 func CustomToken(scheme, token string) easyhttp.Interceptor {
-	if stringutils.IsBlank(scheme) {
-		return APIKey("Authorization", token, AddToHeader)
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		headerutils.SetAuthorization(req.RawRequest(), scheme, token)
+		return do(cli, req)
 	}
-	return APIKey("Authorization", scheme+" "+token, AddToHeader)
 }
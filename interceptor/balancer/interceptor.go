@@ -1,24 +1,53 @@
 package easyhttpbalancer
 
 import (
+	"context"
+
 	"github.com/soyacen/easyhttp"
 )
 
+// Interceptor resolves the host of the request url to endpoints and sends the request to the picked one.
 func Interceptor(opts ...Option) easyhttp.Interceptor {
 	o := defaultOptions()
 	o.apply(opts...)
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		rawRequest := req.RawRequest()
+		target := rawRequest.URL.Host
+		endpoints, err := o.resolver.Resolve(rawRequest.Context(), target)
+		if err != nil {
+			return nil, err
+		}
+		if len(endpoints) == 0 {
+			return nil, ErrNoEndpoint
+		}
 		pickerInfo := PickerInfo{
-			URL:    req.RawRequest().URL,
-			Header: req.RawRequest().Header,
-			Ctx:    req.RawRequest().Context(),
+			URL:       rawRequest.URL,
+			Header:    rawRequest.Header,
+			Ctx:       rawRequest.Context(),
+			Target:    target,
+			Endpoints: endpoints,
 		}
 		pickResult, err := o.picker.Pick(pickerInfo)
 		if err != nil {
-			return
+			return nil, err
+		}
+		// send a copy with the picked host, so an outer interceptor, e.g. retry, still sees the target
+		u := *rawRequest.URL
+		u.Host = pickResult.Host
+		newRequest := rawRequest.WithContext(rawRequest.Context())
+		newRequest.URL = &u
+		if o.keepHostHeader {
+			// TLSDialer handshakes with the target name instead of the picked address
+			newRequest = newRequest.WithContext(context.WithValue(newRequest.Context(), serverNameKey{}, rawRequest.URL.Hostname()))
+		} else {
+			newRequest.Host = pickResult.Host
 		}
-		req.RawRequest().URL.Host = pickResult.Host
+		req.SetRawRequest(newRequest)
+		defer req.SetRawRequest(rawRequest)
 		reply, err = do(cli, req)
+		if donePicker, ok := o.picker.(DonePicker); ok {
+			donePicker.Done(pickResult, reply, err)
+		}
 		return reply, err
 	}
 }
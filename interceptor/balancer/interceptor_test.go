@@ -0,0 +1,257 @@
+package easyhttpbalancer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func newServers(n int) ([]*httptest.Server, []string) {
+	servers := make([]*httptest.Server, 0, n)
+	hosts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Host", r.Host)
+		}))
+		u, _ := url.Parse(server.URL)
+		servers = append(servers, server)
+		hosts = append(hosts, u.Host)
+	}
+	return servers, hosts
+}
+
+func TestStaticResolver(t *testing.T) {
+	servers, hosts := newServers(3)
+	for _, server := range servers {
+		defer server.Close()
+	}
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(
+		WithResolver(NewStaticHostsResolver(hosts...)),
+		WithPicker(NewRoundRobinPicker()),
+	)))
+	for i := 0; i < 6; i++ {
+		reply, err := client.Get(context.Background(), "http://user-service/users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host := reply.RawResponse().Header.Get("X-Host"); host != hosts[i%3] {
+			t.Fatalf("request %d, expected host %s, got %s", i, hosts[i%3], host)
+		}
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "easyhttp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "endpoints")
+	if err := ioutil.WriteFile(path, []byte("# user service\n10.0.0.1:80 weight=2 zone=a\n10.0.0.2:80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewFileResolver(path, time.Nanosecond)
+	endpoints, err := resolver.Resolve(context.Background(), "user-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 2 || endpoints[0].Weight != 2 || endpoints[0].Metadata["zone"] != "a" || endpoints[1].Weight != 1 {
+		t.Fatalf("unexpected endpoints %+v", endpoints)
+	}
+
+	modTime := time.Now().Add(time.Second)
+	if err := ioutil.WriteFile(path, []byte("10.0.0.3:80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err = resolver.Resolve(context.Background(), "user-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].Host != "10.0.0.3:80" {
+		t.Fatalf("unexpected endpoints %+v", endpoints)
+	}
+	// a broken file keeps the previous endpoints
+	modTime = modTime.Add(time.Second)
+	if err := ioutil.WriteFile(path, []byte("10.0.0.4:80 weight=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err = resolver.Resolve(context.Background(), "user-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].Host != "10.0.0.3:80" {
+		t.Fatalf("unexpected endpoints %+v", endpoints)
+	}
+}
+
+type closingRegistry struct {
+	mu      sync.Mutex
+	watches int
+}
+
+// Watch sends a single update with the number of the watch as weight, then ends the watch.
+func (r *closingRegistry) Watch(ctx context.Context, service string) (<-chan []Endpoint, error) {
+	r.mu.Lock()
+	r.watches++
+	weight := r.watches
+	r.mu.Unlock()
+	updates := make(chan []Endpoint, 1)
+	updates <- []Endpoint{{Host: service + ":80", Weight: weight}}
+	close(updates)
+	return updates, nil
+}
+
+func TestRegistryResolverRewatch(t *testing.T) {
+	resolver := NewRegistryResolver(&closingRegistry{})
+	defer resolver.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		endpoints, err := resolver.Resolve(context.Background(), "user-service")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(endpoints) != 1 {
+			t.Fatalf("unexpected endpoints %+v", endpoints)
+		}
+		if endpoints[0].Weight > 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the service is not watched again")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestTLSDialerServerName(t *testing.T) {
+	var mu sync.Mutex
+	var serverNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+	}))
+	server.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		mu.Lock()
+		serverNames = append(serverNames, hello.ServerName)
+		mu.Unlock()
+		return nil, nil
+	}}
+	server.StartTLS()
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client := easyhttp.NewClient(
+		easyhttp.WithTransport(&http.Transport{DialTLSContext: TLSDialer(nil, &tls.Config{RootCAs: roots})}),
+		easyhttp.WithChainInterceptor(Interceptor(
+			WithResolver(NewStaticHostsResolver(u.Host)),
+			WithKeepHostHeader(),
+		)),
+	)
+	// the certificate of httptest is issued for example.com, not for the picked address
+	reply, err := client.Get(context.Background(), "https://example.com:"+port+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host := reply.RawResponse().Header.Get("X-Host"); host != "example.com:"+port {
+		t.Fatalf("unexpected host header %s", host)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(serverNames) != 1 || serverNames[0] != "example.com" {
+		t.Fatalf("unexpected server names %v", serverNames)
+	}
+}
+
+func TestHealthPicker(t *testing.T) {
+	servers, hosts := newServers(2)
+	for _, server := range servers {
+		defer server.Close()
+	}
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	u, _ := url.Parse(broken.URL)
+	hosts = append(hosts, u.Host)
+
+	picker := NewHealthPicker(NewRoundRobinPicker(), WithConsecutiveFailures(2), WithEjectionTime(time.Minute, time.Hour))
+	defer picker.Close()
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(
+		WithResolver(NewStaticHostsResolver(hosts...)),
+		WithPicker(picker),
+	)))
+	failures := 0
+	for i := 0; i < 30; i++ {
+		reply, err := client.Get(context.Background(), "http://user-service/users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.RawResponse().StatusCode == http.StatusInternalServerError {
+			failures++
+		}
+	}
+	if failures != 2 {
+		t.Fatalf("expected the broken endpoint to be ejected after 2 failures, got %d failures", failures)
+	}
+}
+
+func TestHealthCheckMaxEjection(t *testing.T) {
+	var hosts []string
+	for i := 0; i < 2; i++ {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		u, _ := url.Parse(server.URL)
+		hosts = append(hosts, u.Host)
+	}
+	picker := NewHealthPicker(NewRoundRobinPicker(), WithHealthCheck("/health", time.Hour, nil))
+	defer picker.Close()
+	info := PickerInfo{
+		URL:       &url.URL{Scheme: "http"},
+		Target:    "user-service",
+		Endpoints: []Endpoint{{Host: hosts[0], Weight: 1}, {Host: hosts[1], Weight: 1}},
+	}
+	if _, err := picker.Pick(info); err != nil {
+		t.Fatal(err)
+	}
+	picker.healthCheck()
+	unhealthy := 0
+	for _, host := range hosts {
+		if picker.hosts[host].unhealthy {
+			unhealthy++
+		}
+	}
+	if unhealthy != 1 {
+		t.Fatalf("expected 1 of 2 endpoints ejected by the 50%% cap, got %d", unhealthy)
+	}
+
+	// the health of a removed endpoint is forgotten
+	info.Endpoints = info.Endpoints[:1]
+	if _, err := picker.Pick(info); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := picker.hosts[hosts[1]]; ok {
+		t.Fatal("the removed endpoint is still tracked")
+	}
+}
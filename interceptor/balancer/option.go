@@ -1,7 +1,10 @@
 package easyhttpbalancer
 
 type options struct {
-	picker Picker
+	picker   Picker
+	resolver Resolver
+
+	keepHostHeader bool
 }
 
 func (o *options) apply(opts ...Option) {
@@ -14,7 +17,8 @@ type Option func(o *options)
 
 func defaultOptions() *options {
 	return &options{
-		picker: NewFirstPicker(),
+		picker:   NewFirstPicker(),
+		resolver: NewHostListResolver(),
 	}
 }
 
@@ -23,3 +27,20 @@ func WithPicker(picker Picker) Option {
 		o.picker = picker
 	}
 }
+
+// WithResolver sets the resolver of the endpoints, default is the HostListResolver.
+func WithResolver(resolver Resolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
+// WithKeepHostHeader sends the target as the Host header instead of the picked host,
+// e.g. when a DNSResolver resolves a virtual host to its addresses.
+// For https targets, set TLSDialer as the DialTLSContext of the transport,
+// so the TLS server name and the certificate verification use the target name.
+func WithKeepHostHeader() Option {
+	return func(o *options) {
+		o.keepHostHeader = true
+	}
+}
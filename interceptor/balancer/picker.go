@@ -2,20 +2,60 @@ package easyhttpbalancer
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soyacen/easyhttp"
 )
 
+// ErrNoEndpoint is returned when the resolver finds no endpoint.
+var ErrNoEndpoint = errors.New("easyhttpbalancer: no endpoint available")
+
 type PickerInfo struct {
 	URL    *url.URL
 	Header http.Header
 	Ctx    context.Context
+	// Target is the host of the request url, resolved to Endpoints
+	Target string
+	// Endpoints is the live endpoint set of Target, it is never empty
+	Endpoints []Endpoint
 }
 
 type PickResult struct {
-	Host string
+	Host     string
+	Endpoint Endpoint
+
+	// picked is the time of the pick, used to measure the latency of the call
+	picked time.Time
 }
 
 type Picker interface {
 	Pick(pickerInfo PickerInfo) (PickResult, error)
 }
+
+// DonePicker is a Picker notified of the outcome of every call sent to a picked endpoint,
+// like the Done callback of gRPC balancers.
+type DonePicker interface {
+	Picker
+	Done(pickResult PickResult, reply *easyhttp.Reply, err error)
+}
+
+func newPickResult(endpoint Endpoint) PickResult {
+	return PickResult{Host: endpoint.Host, Endpoint: endpoint, picked: time.Now()}
+}
+
+// endpointsKey identifies an endpoint set, so pickers can rebuild their state when it changes.
+func endpointsKey(endpoints []Endpoint) string {
+	var b strings.Builder
+	for _, endpoint := range endpoints {
+		b.WriteString(endpoint.Host)
+		b.WriteByte('/')
+		b.WriteString(strconv.Itoa(endpoint.Weight))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -1,18 +1,12 @@
 package easyhttpbalancer
 
-import (
-	"errors"
-	"strings"
-)
-
 type FirstPicker struct{}
 
 func (picker *FirstPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
-	hosts := strings.Split(pickerInfo.URL.Host, ",")
-	if len(hosts) <= 0 {
-		return PickResult{}, errors.New("host list is empty")
+	if len(pickerInfo.Endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
 	}
-	return PickResult{Host: hosts[0]}, nil
+	return newPickResult(pickerInfo.Endpoints[0]), nil
 }
 
 func NewFirstPicker() *FirstPicker {
@@ -1,9 +1,6 @@
 package easyhttpbalancer
 
 import (
-	"errors"
-	"strings"
-
 	"github.com/cespare/xxhash"
 )
 
@@ -20,9 +17,9 @@ type HashPicker struct {
 }
 
 func (picker *HashPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
-	hosts := strings.Split(pickerInfo.URL.Host, ",")
-	if len(hosts) <= 0 {
-		return PickResult{}, errors.New("host list is empty")
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
 	}
 	var val string
 	if picker.keyLocation == Header {
@@ -31,8 +28,8 @@ func (picker *HashPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
 		val = pickerInfo.URL.Query().Get(picker.key)
 	}
 	sum := xxhash.Sum64String(val)
-	index := int(sum) % len(hosts)
-	return PickResult{Host: hosts[index]}, nil
+	index := sum % uint64(len(endpoints))
+	return newPickResult(endpoints[index]), nil
 }
 
 func NewHashPicker(key string, keyLocation KeyLocation) *HashPicker {
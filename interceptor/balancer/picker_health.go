@@ -0,0 +1,345 @@
+package easyhttpbalancer
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// FailureClassifier reports whether the outcome of a call is a failure of the endpoint.
+type FailureClassifier func(reply *easyhttp.Reply, err error) bool
+
+// DefaultFailureClassifier treats errors, 5xx replies and calls without reply as failures, see easyhttp.IsFailure.
+// A StatusError of a 4xx reply and a canceled call are not failures of the endpoint.
+func DefaultFailureClassifier(reply *easyhttp.Reply, err error) bool {
+	return easyhttp.IsFailure(reply, err)
+}
+
+type healthOptions struct {
+	consecutiveFailures int
+	failureRate         float64
+	failureRateMinCalls int
+	failureRateInterval time.Duration
+	baseEjectionTime    time.Duration
+	maxEjectionTime     time.Duration
+	maxEjectionPercent  int
+	classifier          FailureClassifier
+
+	healthCheckPath     string
+	healthCheckInterval time.Duration
+	healthCheckClient   *http.Client
+}
+
+// HealthOption configures a HealthPicker.
+type HealthOption func(o *healthOptions)
+
+// WithConsecutiveFailures ejects an endpoint after n consecutive failures, 0 disables it. Default is 5.
+func WithConsecutiveFailures(n int) HealthOption {
+	return func(o *healthOptions) {
+		o.consecutiveFailures = n
+	}
+}
+
+// WithFailureRate ejects an endpoint whose failure rate reaches rate (0-1),
+// computed over fixed windows of interval having at least minCalls calls.
+func WithFailureRate(rate float64, minCalls int, interval time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		o.failureRate = rate
+		o.failureRateMinCalls = minCalls
+		o.failureRateInterval = interval
+	}
+}
+
+// WithEjectionTime sets the ejection time, it doubles each time an endpoint is ejected again, up to max.
+// Defaults are 30s and 5m.
+func WithEjectionTime(base, max time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		o.baseEjectionTime = base
+		o.maxEjectionTime = max
+	}
+}
+
+// WithMaxEjectionPercent sets the maximum percentage of the endpoints of a target that can be ejected,
+// at least one endpoint can always be ejected. Default is 50.
+func WithMaxEjectionPercent(percent int) HealthOption {
+	return func(o *healthOptions) {
+		o.maxEjectionPercent = percent
+	}
+}
+
+// WithFailureClassifier sets the classifier of failures, default is DefaultFailureClassifier.
+func WithFailureClassifier(classifier FailureClassifier) HealthOption {
+	return func(o *healthOptions) {
+		o.classifier = classifier
+	}
+}
+
+// WithHealthCheck actively checks the endpoints every interval by a GET request to path,
+// an endpoint is unhealthy until it replies 2xx again. client may be nil.
+func WithHealthCheck(path string, interval time.Duration, client *http.Client) HealthOption {
+	return func(o *healthOptions) {
+		o.healthCheckPath = path
+		o.healthCheckInterval = interval
+		o.healthCheckClient = client
+	}
+}
+
+// HealthPicker wraps a Picker and hides unhealthy endpoints from it.
+//
+// Endpoints are ejected passively from the outcome of the calls (outlier detection)
+// and optionally by active health checks. If every endpoint of a target is unhealthy,
+// all of them are given to the wrapped picker.
+type HealthPicker struct {
+	picker Picker
+	o      *healthOptions
+
+	mu        sync.Mutex
+	hosts     map[string]*hostHealth
+	targets   map[string]*targetEndpoints
+	lastPrune time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+type hostHealth struct {
+	consecutiveFailures int
+	windowStart         time.Time
+	windowCalls         int
+	windowFailures      int
+	ejectionCount       int
+	ejectedUntil        time.Time
+	lastEjection        time.Time
+	unhealthy           bool
+}
+
+type targetEndpoints struct {
+	scheme    string
+	endpoints []Endpoint
+	key       string
+	lastPick  time.Time
+}
+
+// targetIdleTime is the time after which a target that is not picked is forgotten.
+const targetIdleTime = 10 * time.Minute
+
+func NewHealthPicker(picker Picker, opts ...HealthOption) *HealthPicker {
+	o := &healthOptions{
+		consecutiveFailures: 5,
+		baseEjectionTime:    30 * time.Second,
+		maxEjectionTime:     5 * time.Minute,
+		maxEjectionPercent:  50,
+		classifier:          DefaultFailureClassifier,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	p := &HealthPicker{
+		picker:  picker,
+		o:       o,
+		hosts:   make(map[string]*hostHealth),
+		targets: make(map[string]*targetEndpoints),
+		stop:    make(chan struct{}),
+	}
+	if o.healthCheckPath != "" && o.healthCheckInterval > 0 {
+		if o.healthCheckClient == nil {
+			o.healthCheckClient = &http.Client{Timeout: o.healthCheckInterval}
+		}
+		go p.healthCheckLoop()
+	}
+	return p
+}
+
+func (p *HealthPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
+	now := time.Now()
+	p.mu.Lock()
+	p.updateTarget(pickerInfo, now)
+	healthy := make([]Endpoint, 0, len(pickerInfo.Endpoints))
+	for _, endpoint := range pickerInfo.Endpoints {
+		if !p.health(endpoint.Host).isEjected(now) {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	p.mu.Unlock()
+	if len(healthy) > 0 {
+		pickerInfo.Endpoints = healthy
+	}
+	return p.picker.Pick(pickerInfo)
+}
+
+func (p *HealthPicker) Done(pickResult PickResult, reply *easyhttp.Reply, err error) {
+	if donePicker, ok := p.picker.(DonePicker); ok {
+		donePicker.Done(pickResult, reply, err)
+	}
+	failed := p.o.classifier(reply, err)
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.health(pickResult.Host)
+	if p.o.failureRateInterval > 0 && now.Sub(h.windowStart) >= p.o.failureRateInterval {
+		h.windowStart, h.windowCalls, h.windowFailures = now, 0, 0
+	}
+	h.windowCalls++
+	if !failed {
+		h.consecutiveFailures = 0
+		// an endpoint healthy for the max ejection time forgets its past ejections
+		if h.ejectionCount > 0 && now.Sub(h.lastEjection) >= p.o.maxEjectionTime {
+			h.ejectionCount = 0
+		}
+		return
+	}
+	h.consecutiveFailures++
+	h.windowFailures++
+	if h.isEjected(now) {
+		return
+	}
+	tripped := p.o.consecutiveFailures > 0 && h.consecutiveFailures >= p.o.consecutiveFailures
+	if p.o.failureRate > 0 && h.windowCalls >= p.o.failureRateMinCalls &&
+		float64(h.windowFailures)/float64(h.windowCalls) >= p.o.failureRate {
+		tripped = true
+	}
+	if tripped && p.canEject(pickResult.Host, now) {
+		h.eject(now, p.o.baseEjectionTime, p.o.maxEjectionTime)
+	}
+}
+
+// Close stops the active health checks.
+func (p *HealthPicker) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	return nil
+}
+
+// updateTarget records the endpoints of the target, the health of the endpoints that disappeared is forgotten.
+func (p *HealthPicker) updateTarget(pickerInfo PickerInfo, now time.Time) {
+	key := endpointsKey(pickerInfo.Endpoints)
+	target, ok := p.targets[pickerInfo.Target]
+	changed := !ok || target.key != key
+	if changed {
+		target = &targetEndpoints{scheme: pickerInfo.URL.Scheme, endpoints: pickerInfo.Endpoints, key: key}
+		p.targets[pickerInfo.Target] = target
+	}
+	target.lastPick = now
+	if changed || now.Sub(p.lastPrune) >= time.Minute {
+		p.prune(now)
+	}
+}
+
+// prune forgets the idle targets and the hosts that are no longer endpoints of a target.
+func (p *HealthPicker) prune(now time.Time) {
+	p.lastPrune = now
+	live := make(map[string]struct{}, len(p.hosts))
+	for name, target := range p.targets {
+		if now.Sub(target.lastPick) >= targetIdleTime {
+			delete(p.targets, name)
+			continue
+		}
+		for _, endpoint := range target.endpoints {
+			live[endpoint.Host] = struct{}{}
+		}
+	}
+	for host := range p.hosts {
+		if _, ok := live[host]; !ok {
+			delete(p.hosts, host)
+		}
+	}
+}
+
+func (p *HealthPicker) health(host string) *hostHealth {
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &hostHealth{windowStart: time.Now()}
+		p.hosts[host] = h
+	}
+	return h
+}
+
+// canEject reports whether host can be ejected without exceeding the max ejection percent of its targets.
+func (p *HealthPicker) canEject(host string, now time.Time) bool {
+	for _, target := range p.targets {
+		found, ejected := false, 0
+		for _, endpoint := range target.endpoints {
+			if endpoint.Host == host {
+				found = true
+			}
+			if h, ok := p.hosts[endpoint.Host]; ok && h.isEjected(now) {
+				ejected++
+			}
+		}
+		max := len(target.endpoints) * p.o.maxEjectionPercent / 100
+		if max < 1 {
+			max = 1
+		}
+		if found && ejected >= max {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *hostHealth) isEjected(now time.Time) bool {
+	return h.unhealthy || now.Before(h.ejectedUntil)
+}
+
+func (h *hostHealth) eject(now time.Time, base, max time.Duration) {
+	ejectionTime := base << uint(h.ejectionCount)
+	if ejectionTime > max || ejectionTime <= 0 {
+		ejectionTime = max
+	}
+	h.ejectionCount++
+	h.lastEjection = now
+	h.ejectedUntil = now.Add(ejectionTime)
+	h.consecutiveFailures = 0
+	h.windowStart, h.windowCalls, h.windowFailures = now, 0, 0
+}
+
+func (p *HealthPicker) healthCheckLoop() {
+	ticker := time.NewTicker(p.o.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.healthCheck()
+		}
+	}
+}
+
+func (p *HealthPicker) healthCheck() {
+	urls := make(map[string]string)
+	p.mu.Lock()
+	for _, target := range p.targets {
+		scheme := target.scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		for _, endpoint := range target.endpoints {
+			urls[endpoint.Host] = scheme + "://" + endpoint.Host + p.o.healthCheckPath
+		}
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for host, u := range urls {
+		wg.Add(1)
+		go func(host, u string) {
+			defer wg.Done()
+			healthy := false
+			if resp, err := p.o.healthCheckClient.Get(u); err == nil {
+				healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+				_ = resp.Body.Close()
+			}
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			h := p.health(host)
+			// a failed check respects the max ejection percent, like the passive ejection
+			if !healthy && !h.unhealthy && !p.canEject(host, time.Now()) {
+				return
+			}
+			h.unhealthy = !healthy
+		}(host, u)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,60 @@
+package easyhttpbalancer
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// LeastRequestPicker picks the endpoint with the fewest outstanding requests relative to its weight,
+// ties are broken randomly. It must be notified of the outcome of the calls, which the Interceptor does.
+type LeastRequestPicker struct {
+	tracker *loadTracker
+
+	mu       sync.Mutex
+	intnRand IntnRand
+}
+
+func NewLeastRequestPicker() *LeastRequestPicker {
+	return &LeastRequestPicker{
+		tracker:  newLoadTracker(defaultLatencyDecay),
+		intnRand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (picker *LeastRequestPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
+	}
+	picker.tracker.mu.Lock()
+	candidates := make([]int, 0, len(endpoints))
+	var min float64
+	for i, endpoint := range endpoints {
+		weight := endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		load := float64(picker.tracker.outstanding(endpoint.Host)) / float64(weight)
+		if len(candidates) == 0 || load < min {
+			min = load
+			candidates = candidates[:0]
+		}
+		if load == min {
+			candidates = append(candidates, i)
+		}
+	}
+	picker.mu.Lock()
+	index := candidates[picker.intnRand.Intn(len(candidates))]
+	picker.mu.Unlock()
+	// select and start under the same lock, so concurrent picks see each other
+	picker.tracker.load(endpoints[index].Host).outstanding++
+	picker.tracker.mu.Unlock()
+	return newPickResult(endpoints[index]), nil
+}
+
+func (picker *LeastRequestPicker) Done(pickResult PickResult, reply *easyhttp.Reply, err error) {
+	picker.tracker.done(pickResult)
+}
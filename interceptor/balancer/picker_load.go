@@ -0,0 +1,74 @@
+package easyhttpbalancer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// loadTracker tracks the outstanding requests and the peak EWMA latency of the endpoints.
+type loadTracker struct {
+	mu    sync.Mutex
+	decay time.Duration
+	hosts map[string]*hostLoad
+}
+
+type hostLoad struct {
+	outstanding int64
+	latency     float64
+	lastUpdate  time.Time
+}
+
+func newLoadTracker(decay time.Duration) *loadTracker {
+	return &loadTracker{decay: decay, hosts: make(map[string]*hostLoad)}
+}
+
+func (t *loadTracker) load(host string) *hostLoad {
+	l, ok := t.hosts[host]
+	if !ok {
+		l = &hostLoad{}
+		t.hosts[host] = l
+	}
+	return l
+}
+
+func (t *loadTracker) start(host string) {
+	t.mu.Lock()
+	t.load(host).outstanding++
+	t.mu.Unlock()
+}
+
+// done ends a call to host, and updates its peak EWMA latency with the time since pickResult was picked:
+// a latency above the average replaces it at once, so a slowing endpoint is avoided right away,
+// and lower latencies lower the average as they decay.
+func (t *loadTracker) done(pickResult PickResult) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l := t.load(pickResult.Host)
+	if l.outstanding > 0 {
+		l.outstanding--
+	}
+	if pickResult.picked.IsZero() {
+		return
+	}
+	rtt := float64(now.Sub(pickResult.picked))
+	if l.lastUpdate.IsZero() || rtt > l.latency {
+		l.latency = rtt
+	} else {
+		// the older the previous value, the less it weighs
+		w := math.Exp(-float64(now.Sub(l.lastUpdate)) / float64(t.decay))
+		l.latency = l.latency*w + rtt*(1-w)
+	}
+	l.lastUpdate = now
+}
+
+func (t *loadTracker) outstanding(host string) int64 {
+	return t.load(host).outstanding
+}
+
+// cost is the expected latency of a new call to host, endpoints without latency yet are preferred.
+func (t *loadTracker) cost(host string) float64 {
+	l := t.load(host)
+	return l.latency * float64(l.outstanding+1)
+}
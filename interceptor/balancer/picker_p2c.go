@@ -0,0 +1,63 @@
+package easyhttpbalancer
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+const defaultLatencyDecay = 10 * time.Second
+
+// P2CPicker is the power of two choices with peak EWMA latency:
+// it picks two endpoints at random and sends to the one of the lower EWMA latency times outstanding requests.
+// It must be notified of the outcome of the calls, which the Interceptor does.
+type P2CPicker struct {
+	tracker *loadTracker
+
+	mu       sync.Mutex
+	intnRand IntnRand
+}
+
+// NewP2CPicker creates a P2CPicker, decay is the time for the latency of an endpoint to decay,
+// 0 means 10 seconds.
+func NewP2CPicker(decay time.Duration) *P2CPicker {
+	if decay <= 0 {
+		decay = defaultLatencyDecay
+	}
+	return &P2CPicker{
+		tracker:  newLoadTracker(decay),
+		intnRand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (picker *P2CPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
+	}
+	if len(endpoints) == 1 {
+		picker.tracker.start(endpoints[0].Host)
+		return newPickResult(endpoints[0]), nil
+	}
+	picker.mu.Lock()
+	a := picker.intnRand.Intn(len(endpoints))
+	b := picker.intnRand.Intn(len(endpoints) - 1)
+	picker.mu.Unlock()
+	if b >= a {
+		b++
+	}
+	// compare and start under the same lock, so concurrent picks see each other
+	picker.tracker.mu.Lock()
+	if picker.tracker.cost(endpoints[b].Host) < picker.tracker.cost(endpoints[a].Host) {
+		a = b
+	}
+	picker.tracker.load(endpoints[a].Host).outstanding++
+	picker.tracker.mu.Unlock()
+	return newPickResult(endpoints[a]), nil
+}
+
+func (picker *P2CPicker) Done(pickResult PickResult, reply *easyhttp.Reply, err error) {
+	picker.tracker.done(pickResult)
+}
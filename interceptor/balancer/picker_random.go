@@ -1,9 +1,8 @@
 package easyhttpbalancer
 
 import (
-	"errors"
 	"math/rand"
-	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,16 +11,19 @@ type IntnRand interface {
 }
 
 type RandomPicker struct {
+	mu       sync.Mutex
 	intnRand IntnRand
 }
 
 func (picker *RandomPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
-	hosts := strings.Split(pickerInfo.URL.Host, ",")
-	if len(hosts) <= 0 {
-		return PickResult{}, errors.New("host list is empty")
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
 	}
-	index := picker.intnRand.Intn(len(hosts))
-	return PickResult{Host: hosts[index]}, nil
+	picker.mu.Lock()
+	index := picker.intnRand.Intn(len(endpoints))
+	picker.mu.Unlock()
+	return newPickResult(endpoints[index]), nil
 }
 
 func NewRandomPicker() *RandomPicker {
@@ -0,0 +1,90 @@
+package easyhttpbalancer
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cespare/xxhash"
+)
+
+const defaultVirtualNodes = 160
+
+// RingHashPicker is a consistent hash ring (ketama), each endpoint owns virtual nodes in proportion to its weight.
+// Adding or removing an endpoint only moves the keys of its own virtual nodes,
+// so the affinity of the other keys survives changes of the endpoint set.
+type RingHashPicker struct {
+	key          string
+	keyLocation  KeyLocation
+	virtualNodes int
+
+	mu    sync.Mutex
+	rings map[string]*hashRing
+}
+
+type hashRing struct {
+	key    string
+	hashes []uint64
+	nodes  map[uint64]Endpoint
+}
+
+// NewRingHashPicker creates a RingHashPicker hashing the value of key found at keyLocation,
+// virtualNodes is the number of virtual nodes per weight unit, 0 means 160.
+func NewRingHashPicker(key string, keyLocation KeyLocation, virtualNodes int) *RingHashPicker {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+	return &RingHashPicker{
+		key:          key,
+		keyLocation:  keyLocation,
+		virtualNodes: virtualNodes,
+		rings:        make(map[string]*hashRing),
+	}
+}
+
+func (picker *RingHashPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
+	}
+	var val string
+	if picker.keyLocation == Header {
+		val = pickerInfo.Header.Get(picker.key)
+	} else {
+		val = pickerInfo.URL.Query().Get(picker.key)
+	}
+	ring := picker.ring(pickerInfo.Target, endpoints)
+	sum := xxhash.Sum64String(val)
+	index := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= sum })
+	if index == len(ring.hashes) {
+		index = 0
+	}
+	return newPickResult(ring.nodes[ring.hashes[index]]), nil
+}
+
+func (picker *RingHashPicker) ring(target string, endpoints []Endpoint) *hashRing {
+	picker.mu.Lock()
+	defer picker.mu.Unlock()
+	key := endpointsKey(endpoints)
+	if ring, ok := picker.rings[target]; ok && ring.key == key {
+		return ring
+	}
+	ring := &hashRing{key: key, nodes: make(map[uint64]Endpoint)}
+	for _, endpoint := range endpoints {
+		weight := endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		for i := 0; i < picker.virtualNodes*weight; i++ {
+			hash := xxhash.Sum64String(endpoint.Host + "-" + strconv.Itoa(i))
+			if _, ok := ring.nodes[hash]; ok {
+				continue
+			}
+			ring.nodes[hash] = endpoint
+			ring.hashes = append(ring.hashes, hash)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	picker.rings[target] = ring
+	return ring
+}
@@ -1,8 +1,6 @@
 package easyhttpbalancer
 
 import (
-	"errors"
-	"strings"
 	"sync"
 )
 
@@ -18,11 +16,12 @@ func NewRoundRobinPicker() *RoundRobinPicker {
 func (picker *RoundRobinPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
 	picker.mu.Lock()
 	defer picker.mu.Unlock()
-	hosts := strings.Split(pickerInfo.URL.Host, ",")
-	if len(hosts) <= 0 {
-		return PickResult{}, errors.New("host list is empty")
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
 	}
-	index := picker.nexts[pickerInfo.URL.Host]
-	picker.nexts[pickerInfo.URL.Host] = (index + 1) % len(hosts)
-	return PickResult{Host: hosts[index]}, nil
+	// the endpoint set may have shrunk since the last pick
+	index := picker.nexts[pickerInfo.Target] % len(endpoints)
+	picker.nexts[pickerInfo.Target] = (index + 1) % len(endpoints)
+	return newPickResult(endpoints[index]), nil
 }
@@ -0,0 +1,128 @@
+package easyhttpbalancer
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWeightedRoundRobinPicker(t *testing.T) {
+	picker := NewWeightedRoundRobinPicker()
+	info := PickerInfo{
+		Target:    "user-service",
+		Endpoints: []Endpoint{{Host: "a", Weight: 5}, {Host: "b", Weight: 1}, {Host: "c", Weight: 1}},
+	}
+	var hosts []string
+	for i := 0; i < 7; i++ {
+		result, err := picker.Pick(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts = append(hosts, result.Host)
+	}
+	if got := strings.Join(hosts, ""); got != "aabacaa" {
+		t.Fatalf("unexpected sequence %s", got)
+	}
+}
+
+func TestLeastRequestPicker(t *testing.T) {
+	picker := NewLeastRequestPicker()
+	info := PickerInfo{Target: "user-service", Endpoints: []Endpoint{{Host: "a", Weight: 1}, {Host: "b", Weight: 1}}}
+	first, _ := picker.Pick(info)
+	second, _ := picker.Pick(info)
+	if first.Host == second.Host {
+		t.Fatalf("expected distinct hosts, got %s twice", first.Host)
+	}
+	picker.Done(first, nil, nil)
+	third, _ := picker.Pick(info)
+	if third.Host != first.Host {
+		t.Fatalf("expected %s, got %s", first.Host, third.Host)
+	}
+}
+
+func TestP2CPicker(t *testing.T) {
+	picker := NewP2CPicker(time.Second)
+	info := PickerInfo{Target: "user-service", Endpoints: []Endpoint{{Host: "slow", Weight: 1}, {Host: "fast", Weight: 1}}}
+	picker.tracker.start("slow")
+	picker.Done(PickResult{Host: "slow", picked: time.Now().Add(-time.Second)}, nil, nil)
+	picker.tracker.start("fast")
+	picker.Done(PickResult{Host: "fast", picked: time.Now().Add(-time.Millisecond)}, nil, nil)
+	for i := 0; i < 10; i++ {
+		result, err := picker.Pick(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Host != "fast" {
+			t.Fatalf("expected fast, got %s", result.Host)
+		}
+		picker.Done(result, nil, nil)
+	}
+}
+
+func TestRingHashPicker(t *testing.T) {
+	picker := NewRingHashPicker("X-User", Header, 0)
+	endpoints := []Endpoint{{Host: "a", Weight: 1}, {Host: "b", Weight: 1}, {Host: "c", Weight: 1}}
+	u, _ := url.Parse("http://user-service/users")
+	pick := func(user string, endpoints []Endpoint) string {
+		header := make(http.Header)
+		header.Set("X-User", user)
+		result, err := picker.Pick(PickerInfo{Target: "user-service", URL: u, Header: header, Endpoints: endpoints})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Host
+	}
+	users := []string{"alice", "bob", "carol", "dave", "eve", "frank", "grace", "heidi"}
+	before := make(map[string]string)
+	for _, user := range users {
+		before[user] = pick(user, endpoints)
+		if again := pick(user, endpoints); again != before[user] {
+			t.Fatalf("user %s moved from %s to %s", user, before[user], again)
+		}
+	}
+	// removing c only moves the users of c
+	for _, user := range users {
+		if host := pick(user, endpoints[:2]); before[user] != "c" && host != before[user] {
+			t.Fatalf("user %s moved from %s to %s", user, before[user], host)
+		}
+	}
+}
+
+func TestLeastRequestPickerConcurrent(t *testing.T) {
+	picker := NewLeastRequestPicker()
+	endpoints := make([]Endpoint, 8)
+	for i := range endpoints {
+		endpoints[i] = Endpoint{Host: strconv.Itoa(i), Weight: 1}
+	}
+	info := PickerInfo{Target: "user-service", Endpoints: endpoints}
+	var wg sync.WaitGroup
+	for i := 0; i < len(endpoints); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			picker.Pick(info)
+		}()
+	}
+	wg.Wait()
+	for _, endpoint := range endpoints {
+		if outstanding := picker.tracker.outstanding(endpoint.Host); outstanding != 1 {
+			t.Fatalf("expected one request per endpoint, %s has %d", endpoint.Host, outstanding)
+		}
+	}
+}
+
+func TestPeakEWMA(t *testing.T) {
+	tracker := newLoadTracker(time.Hour)
+	tracker.start("a")
+	tracker.done(PickResult{Host: "a", picked: time.Now().Add(-10 * time.Millisecond)})
+	// a spike replaces the average at once, despite the long decay
+	tracker.start("a")
+	tracker.done(PickResult{Host: "a", picked: time.Now().Add(-time.Second)})
+	if latency := time.Duration(tracker.load("a").latency); latency < time.Second {
+		t.Fatalf("expected the peak latency, got %v", latency)
+	}
+}
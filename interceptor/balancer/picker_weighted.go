@@ -0,0 +1,60 @@
+package easyhttpbalancer
+
+import (
+	"sync"
+)
+
+// WeightedRoundRobinPicker is the smooth weighted round-robin of nginx,
+// endpoints are picked in proportion to their weight and evenly interleaved.
+type WeightedRoundRobinPicker struct {
+	mu      sync.Mutex
+	targets map[string]*weightedTarget
+}
+
+type weightedTarget struct {
+	key     string
+	weights []weightedEndpoint
+}
+
+type weightedEndpoint struct {
+	endpoint Endpoint
+	current  int
+}
+
+func NewWeightedRoundRobinPicker() *WeightedRoundRobinPicker {
+	return &WeightedRoundRobinPicker{targets: make(map[string]*weightedTarget)}
+}
+
+func (picker *WeightedRoundRobinPicker) Pick(pickerInfo PickerInfo) (PickResult, error) {
+	endpoints := pickerInfo.Endpoints
+	if len(endpoints) <= 0 {
+		return PickResult{}, ErrNoEndpoint
+	}
+	picker.mu.Lock()
+	defer picker.mu.Unlock()
+	key := endpointsKey(endpoints)
+	target, ok := picker.targets[pickerInfo.Target]
+	if !ok || target.key != key {
+		target = &weightedTarget{key: key, weights: make([]weightedEndpoint, 0, len(endpoints))}
+		for _, endpoint := range endpoints {
+			target.weights = append(target.weights, weightedEndpoint{endpoint: endpoint})
+		}
+		picker.targets[pickerInfo.Target] = target
+	}
+	total := 0
+	var best *weightedEndpoint
+	for i := range target.weights {
+		w := &target.weights[i]
+		weight := w.endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		w.current += weight
+		total += weight
+		if best == nil || w.current > best.current {
+			best = w
+		}
+	}
+	best.current -= total
+	return newPickResult(best.endpoint), nil
+}
@@ -0,0 +1,74 @@
+package easyhttpbalancer
+
+import (
+	"context"
+	"strings"
+)
+
+// Endpoint is an address of a service instance.
+type Endpoint struct {
+	// Host is the host or host:port of the instance
+	Host string
+	// Weight is the relative weight of the instance, 1 if unknown
+	Weight int
+	// Metadata holds extra attributes of the instance, e.g. zone or version
+	Metadata map[string]string
+}
+
+// Resolver resolves the target of a request, the host of its url, to the live endpoints of the service.
+type Resolver interface {
+	Resolve(ctx context.Context, target string) ([]Endpoint, error)
+}
+
+// HostListResolver resolves a comma separated host list, e.g. "host1:80,host2:80".
+// It is the default resolver.
+type HostListResolver struct{}
+
+func NewHostListResolver() *HostListResolver {
+	return &HostListResolver{}
+}
+
+func (r *HostListResolver) Resolve(ctx context.Context, target string) ([]Endpoint, error) {
+	hosts := strings.Split(target, ",")
+	endpoints := make([]Endpoint, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host != "" {
+			endpoints = append(endpoints, Endpoint{Host: host, Weight: 1})
+		}
+	}
+	return endpoints, nil
+}
+
+// StaticResolver resolves any target to a fixed endpoint list.
+type StaticResolver struct {
+	endpoints []Endpoint
+}
+
+func NewStaticResolver(endpoints ...Endpoint) *StaticResolver {
+	return &StaticResolver{endpoints: normalize(endpoints)}
+}
+
+// NewStaticHostsResolver creates a StaticResolver of hosts with weight 1.
+func NewStaticHostsResolver(hosts ...string) *StaticResolver {
+	endpoints := make([]Endpoint, 0, len(hosts))
+	for _, host := range hosts {
+		endpoints = append(endpoints, Endpoint{Host: host})
+	}
+	return NewStaticResolver(endpoints...)
+}
+
+func (r *StaticResolver) Resolve(ctx context.Context, target string) ([]Endpoint, error) {
+	return r.endpoints, nil
+}
+
+// normalize sets the default weight of endpoints.
+func normalize(endpoints []Endpoint) []Endpoint {
+	normalized := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Weight <= 0 {
+			endpoint.Weight = 1
+		}
+		normalized = append(normalized, endpoint)
+	}
+	return normalized
+}
@@ -0,0 +1,130 @@
+package easyhttpbalancer
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultDNSTTL = 30 * time.Second
+
+// DNSOption configures a DNSResolver.
+type DNSOption func(r *DNSResolver)
+
+// WithDNSTTL sets how long resolved endpoints are cached before they are looked up again.
+func WithDNSTTL(ttl time.Duration) DNSOption {
+	return func(r *DNSResolver) {
+		r.ttl = ttl
+	}
+}
+
+// WithSRV looks up SRV records "_service._proto.target" instead of A/AAAA records.
+// Only the records of the lowest priority are used, their weight becomes the endpoint weight.
+func WithSRV(service, proto string) DNSOption {
+	return func(r *DNSResolver) {
+		r.srv = true
+		r.service = service
+		r.proto = proto
+	}
+}
+
+// WithNetResolver sets the net.Resolver used for lookups.
+func WithNetResolver(resolver *net.Resolver) DNSOption {
+	return func(r *DNSResolver) {
+		r.resolver = resolver
+	}
+}
+
+// DNSResolver resolves the target "host[:port]" by DNS lookups.
+// Endpoints are cached for the TTL, a failed refresh keeps the stale endpoints.
+type DNSResolver struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+	srv      bool
+	service  string
+	proto    string
+
+	mu    sync.Mutex
+	cache map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	endpoints []Endpoint
+	expire    time.Time
+}
+
+func NewDNSResolver(opts ...DNSOption) *DNSResolver {
+	r := &DNSResolver{
+		resolver: net.DefaultResolver,
+		ttl:      defaultDNSTTL,
+		cache:    make(map[string]*dnsEntry),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *DNSResolver) Resolve(ctx context.Context, target string) ([]Endpoint, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[target]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expire) {
+		return entry.endpoints, nil
+	}
+	endpoints, err := r.lookup(ctx, target)
+	if err != nil {
+		if ok {
+			return entry.endpoints, nil
+		}
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[target] = &dnsEntry{endpoints: endpoints, expire: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return endpoints, nil
+}
+
+func (r *DNSResolver) lookup(ctx context.Context, target string) ([]Endpoint, error) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		host, port = target, ""
+	}
+	if r.srv {
+		_, records, err := r.resolver.LookupSRV(ctx, r.service, r.proto, host)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Priority < records[j].Priority })
+		endpoints := make([]Endpoint, 0, len(records))
+		for _, record := range records {
+			if record.Priority != records[0].Priority {
+				break
+			}
+			endpoints = append(endpoints, Endpoint{
+				Host:     net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))),
+				Weight:   int(record.Weight),
+				Metadata: map[string]string{"priority": strconv.Itoa(int(record.Priority))},
+			})
+		}
+		return normalize(endpoints), nil
+	}
+	addrs, err := r.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]Endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		if port != "" {
+			addr = net.JoinHostPort(addr, port)
+		} else if strings.Contains(addr, ":") {
+			addr = "[" + addr + "]"
+		}
+		endpoints = append(endpoints, Endpoint{Host: addr, Weight: 1})
+	}
+	return endpoints, nil
+}
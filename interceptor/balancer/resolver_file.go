@@ -0,0 +1,109 @@
+package easyhttpbalancer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFileCheckInterval = 5 * time.Second
+
+// FileResolver resolves any target to the endpoints listed in a file, which is reloaded when it changes.
+// Like the DNSResolver, a file that can not be read or parsed keeps the previous endpoints.
+//
+// Each line of the file is an endpoint: "host[:port] [weight=N] [key=value ...]",
+// empty lines and lines starting with # are ignored.
+type FileResolver struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	endpoints []Endpoint
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewFileResolver creates a FileResolver which checks the file for changes at most once per interval.
+func NewFileResolver(path string, interval time.Duration) *FileResolver {
+	if interval <= 0 {
+		interval = defaultFileCheckInterval
+	}
+	return &FileResolver{path: path, interval: interval}
+}
+
+func (r *FileResolver) Resolve(ctx context.Context, target string) ([]Endpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if !r.lastCheck.IsZero() && now.Sub(r.lastCheck) < r.interval {
+		return r.endpoints, nil
+	}
+	r.lastCheck = now
+	info, err := os.Stat(r.path)
+	if err != nil {
+		if r.endpoints != nil {
+			return r.endpoints, nil
+		}
+		return nil, err
+	}
+	if r.endpoints != nil && info.ModTime().Equal(r.modTime) {
+		return r.endpoints, nil
+	}
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		if r.endpoints != nil {
+			return r.endpoints, nil
+		}
+		return nil, err
+	}
+	endpoints, err := parseEndpoints(data)
+	if err != nil {
+		if r.endpoints != nil {
+			return r.endpoints, nil
+		}
+		return nil, err
+	}
+	r.endpoints = endpoints
+	r.modTime = info.ModTime()
+	return r.endpoints, nil
+}
+
+func parseEndpoints(data []byte) ([]Endpoint, error) {
+	endpoints := make([]Endpoint, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		endpoint := Endpoint{Host: fields[0], Weight: 1}
+		for _, field := range fields[1:] {
+			i := strings.Index(field, "=")
+			if i <= 0 {
+				return nil, fmt.Errorf("easyhttpbalancer: invalid attribute %q at line %d", field, line)
+			}
+			key, value := field[:i], field[i+1:]
+			if key == "weight" {
+				weight, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("easyhttpbalancer: invalid weight %q at line %d", value, line)
+				}
+				endpoint.Weight = weight
+				continue
+			}
+			if endpoint.Metadata == nil {
+				endpoint.Metadata = make(map[string]string)
+			}
+			endpoint.Metadata[key] = value
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return normalize(endpoints), scanner.Err()
+}
@@ -0,0 +1,110 @@
+package easyhttpbalancer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// registryRewatchDelay is the pause before watching a service again when its watch ends.
+const registryRewatchDelay = time.Second
+
+// Registry is a service registry, like consul, etcd or nacos.
+type Registry interface {
+	// Watch sends the endpoints of service every time they change, until ctx is done.
+	Watch(ctx context.Context, service string) (<-chan []Endpoint, error)
+}
+
+// RegistryResolver resolves the target as a service name of a Registry.
+// The service is watched from its first resolution until Close, it is watched again when the watch ends.
+type RegistryResolver struct {
+	registry Registry
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu       sync.Mutex
+	services map[string]*registryService
+}
+
+type registryService struct {
+	ready     chan struct{}
+	mu        sync.RWMutex
+	endpoints []Endpoint
+	err       error
+}
+
+func NewRegistryResolver(registry Registry) *RegistryResolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RegistryResolver{
+		registry: registry,
+		ctx:      ctx,
+		cancel:   cancel,
+		services: make(map[string]*registryService),
+	}
+}
+
+// Resolve returns the latest endpoints of the service target, it waits for the first update of a new service.
+func (r *RegistryResolver) Resolve(ctx context.Context, target string) ([]Endpoint, error) {
+	r.mu.Lock()
+	service, ok := r.services[target]
+	if !ok {
+		service = &registryService{ready: make(chan struct{})}
+		r.services[target] = service
+		go r.watch(target, service)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-service.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	return service.endpoints, service.err
+}
+
+func (r *RegistryResolver) watch(target string, service *registryService) {
+	ready := false
+	for {
+		updates, err := r.registry.Watch(r.ctx, target)
+		if err != nil && !ready {
+			service.err = err
+			close(service.ready)
+			// forget the service, so the next resolution watches it again
+			r.mu.Lock()
+			delete(r.services, target)
+			r.mu.Unlock()
+			return
+		}
+		for err == nil {
+			endpoints, ok := <-updates
+			if !ok {
+				break
+			}
+			service.mu.Lock()
+			service.endpoints = normalize(endpoints)
+			service.mu.Unlock()
+			if !ready {
+				ready = true
+				close(service.ready)
+			}
+		}
+		// the watch ended, watch again after a pause and keep the endpoints meanwhile
+		select {
+		case <-r.ctx.Done():
+			if !ready {
+				service.err = r.ctx.Err()
+				close(service.ready)
+			}
+			return
+		case <-time.After(registryRewatchDelay):
+		}
+	}
+}
+
+// Close stops watching all services.
+func (r *RegistryResolver) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,47 @@
+package easyhttpbalancer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+type serverNameKey struct{}
+
+// TLSDialer returns a DialTLSContext function of http.Transport for WithKeepHostHeader.
+// It dials the picked address and handshakes with the name of the target as the TLS server name,
+// whereas the transport would use the picked address, often an IP the certificate is not issued for.
+// The connections are still pooled by picked address.
+func TLSDialer(dialer *net.Dialer, config *tls.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		cfg := &tls.Config{}
+		if config != nil {
+			cfg = config.Clone()
+		}
+		if cfg.ServerName == "" {
+			if serverName, ok := ctx.Value(serverNameKey{}).(string); ok {
+				cfg.ServerName = serverName
+			} else if host, _, err := net.SplitHostPort(addr); err == nil {
+				cfg.ServerName = host
+			}
+		}
+		rawConn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = rawConn.SetDeadline(deadline)
+		}
+		conn := tls.Client(rawConn, cfg)
+		if err := conn.Handshake(); err != nil {
+			_ = rawConn.Close()
+			return nil, err
+		}
+		_ = rawConn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
@@ -0,0 +1,188 @@
+package easyhttpbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	// Closed lets the calls through and counts their failures.
+	Closed State = iota
+	// Open rejects the calls until the open timeout elapses.
+	Open
+	// HalfOpen lets a few probe calls through to decide whether to close or open again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown state %d", int(s))
+	}
+}
+
+// ErrOpen is matched by errors.Is for an *OpenError.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned when a call is rejected by the breaker of Key.
+type OpenError struct {
+	Key   string
+	State State
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("easyhttpbreaker: circuit breaker %q is %s", e.Key, e.State)
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Breaker is the circuit breaker of a key.
+type Breaker struct {
+	key string
+	o   *options
+	// users and lastUsed are guarded by Registry.mu
+	users    int
+	lastUsed time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	window     window
+	openUntil  time.Time
+	probes     int
+	successes  int
+}
+
+func newBreaker(key string, o *options) *Breaker {
+	b := &Breaker{key: key, o: o}
+	if o.windowDuration > 0 {
+		b.window = newTimeWindow(o.windowDuration, o.windowBuckets)
+	} else {
+		b.window = newCountWindow(o.windowSize)
+	}
+	return b
+}
+
+// Key returns the key of the breaker.
+func (b *Breaker) Key() string {
+	return b.key
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, _ := b.current(time.Now())
+	return state
+}
+
+// allow reports whether a call can be made, it returns the generation the call belongs to.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	state, change := b.current(time.Now())
+	generation := b.generation
+	var err error
+	switch state {
+	case Open:
+		err = &OpenError{Key: b.key, State: Open}
+	case HalfOpen:
+		if b.probes >= b.o.halfOpenProbes {
+			err = &OpenError{Key: b.key, State: HalfOpen}
+		} else {
+			b.probes++
+		}
+	}
+	b.mu.Unlock()
+	b.notify(change)
+	return generation, err
+}
+
+// done records the outcome of a call, calls of a previous generation are ignored.
+func (b *Breaker) done(generation uint64, failure, slow bool) {
+	now := time.Now()
+	b.mu.Lock()
+	state, change := b.current(now)
+	if generation != b.generation {
+		b.mu.Unlock()
+		b.notify(change)
+		return
+	}
+	switch state {
+	case Closed:
+		b.window.record(now, failure, slow)
+		if b.tripped(now) {
+			change = b.setState(Open, now)
+		}
+	case HalfOpen:
+		if failure || slow {
+			change = b.setState(Open, now)
+		} else if b.successes++; b.successes >= b.o.halfOpenProbes {
+			change = b.setState(Closed, now)
+		}
+	}
+	b.mu.Unlock()
+	b.notify(change)
+}
+
+func (b *Breaker) tripped(now time.Time) bool {
+	total, failures, slows := b.window.counts(now)
+	if total < b.o.minimumCalls || total == 0 {
+		return false
+	}
+	if b.o.failureRate > 0 && float64(failures)/float64(total) >= b.o.failureRate {
+		return true
+	}
+	return b.o.slowCallRate > 0 && float64(slows)/float64(total) >= b.o.slowCallRate
+}
+
+// current returns the state at now, moving from open to half-open once the open timeout elapsed.
+func (b *Breaker) current(now time.Time) (State, *stateChange) {
+	if b.state == Open && !now.Before(b.openUntil) {
+		return HalfOpen, b.setState(HalfOpen, now)
+	}
+	return b.state, nil
+}
+
+type stateChange struct {
+	from, to State
+}
+
+// setState changes the state and starts a new generation, b.mu must be held.
+func (b *Breaker) setState(state State, now time.Time) *stateChange {
+	if b.state == state {
+		return nil
+	}
+	change := &stateChange{from: b.state, to: state}
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+	switch state {
+	case Closed:
+		b.window.reset()
+	case Open:
+		b.openUntil = now.Add(b.o.openTimeout)
+	}
+	return change
+}
+
+func (b *Breaker) notify(change *stateChange) {
+	if change == nil {
+		return
+	}
+	for _, onStateChange := range b.o.onStateChange {
+		onStateChange(b.key, change.from, change.to)
+	}
+}
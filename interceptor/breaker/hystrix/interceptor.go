@@ -32,8 +32,15 @@ func Interceptor(commandName string, opts ...Option) easyhttp.Interceptor {
 		ErrorPercentThreshold:  o.errorPercentThreshold,
 	})
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		var clientErr error
 		err = hystrix.DoC(req.Context(), commandName, func(ctx context.Context) error {
 			reply, err = do(cli, req)
+			// a StatusError of client error status code is not a failure of the server
+			var statusErr *easyhttp.StatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode < http.StatusInternalServerError {
+				clientErr = err
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -45,6 +52,9 @@ func Interceptor(commandName string, opts ...Option) easyhttp.Interceptor {
 			}
 			return nil
 		}, o.fallbackFunc)
+		if err == nil && clientErr != nil {
+			return reply, clientErr
+		}
 		return reply, err
 	}
 }
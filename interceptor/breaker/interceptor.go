@@ -0,0 +1,105 @@
+package easyhttpbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Registry holds the breakers of the keys.
+type Registry struct {
+	o *options
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+	pruned   time.Time
+}
+
+func NewRegistry(opts ...Option) *Registry {
+	o := defaultOptions()
+	o.apply(opts...)
+	return &Registry{o: o, breakers: make(map[string]*Breaker)}
+}
+
+// Breaker returns the breaker of key, creating it if needed.
+func (r *Registry) Breaker(key string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		b = newBreaker(key, r.o)
+		r.breakers[key] = b
+	}
+	return b
+}
+
+// get returns the breaker of key for a call, it must be given back with put.
+func (r *Registry) get(key string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(time.Now())
+	b, ok := r.breakers[key]
+	if !ok {
+		b = newBreaker(key, r.o)
+		r.breakers[key] = b
+	}
+	b.users++
+	return b
+}
+
+func (r *Registry) put(b *Breaker) {
+	r.mu.Lock()
+	b.users--
+	b.lastUsed = time.Now()
+	r.mu.Unlock()
+}
+
+// prune drops the closed breakers without calls for the idle timeout, at most once per idle timeout.
+func (r *Registry) prune(now time.Time) {
+	if r.o.idleTimeout <= 0 || now.Sub(r.pruned) < r.o.idleTimeout {
+		return
+	}
+	r.pruned = now
+	for key, b := range r.breakers {
+		if b.users == 0 && now.Sub(b.lastUsed) >= r.o.idleTimeout && b.State() == Closed {
+			delete(r.breakers, key)
+		}
+	}
+}
+
+// Breakers returns the breakers created so far.
+func (r *Registry) Breakers() []*Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	return breakers
+}
+
+// Interceptor guards the calls with the breaker of their key.
+func (r *Registry) Interceptor() easyhttp.Interceptor {
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		b := r.get(r.o.keyFunc(req.RawRequest()))
+		defer r.put(b)
+		generation, err := b.allow()
+		if err != nil {
+			if r.o.fallback != nil {
+				return r.o.fallback(req, err)
+			}
+			return nil, err
+		}
+		start := time.Now()
+		reply, err := do(cli, req)
+		slow := r.o.slowCall > 0 && time.Since(start) > r.o.slowCall
+		b.done(generation, r.o.classifier(reply, err), slow)
+		return reply, err
+	}
+}
+
+// Interceptor guards the calls with a breaker per key, see Registry.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	return NewRegistry(opts...).Interceptor()
+}
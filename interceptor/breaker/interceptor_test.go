@@ -0,0 +1,124 @@
+package easyhttpbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestBreaker(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var changes []string
+	registry := NewRegistry(
+		WithCountWindow(4),
+		WithFailureRate(0.5, 4),
+		WithOpenTimeout(50*time.Millisecond),
+		WithOnStateChange(func(key string, from, to State) {
+			changes = append(changes, from.String()+">"+to.String())
+		}),
+	)
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(registry.Interceptor()))
+	for i := 0; i < 4; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := client.Get(context.Background(), server.URL)
+	var openErr *OpenError
+	if !errors.Is(err, ErrOpen) || !errors.As(err, &openErr) || openErr.State != Open {
+		t.Fatalf("expected an open breaker, got %v", err)
+	}
+
+	// a failed probe opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.Background(), server.URL); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected an open breaker, got %v", err)
+	}
+
+	// a successful probe closes it
+	status = http.StatusOK
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if state := registry.Breakers()[0].State(); state != Closed {
+		t.Fatalf("expected a closed breaker, got %s", state)
+	}
+	expected := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected state changes %v", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("unexpected state changes %v", changes)
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(
+		WithTimeWindow(time.Minute, 6),
+		WithFailureRate(0, 2),
+		WithSlowCall(10*time.Millisecond, 1),
+		WithFallback(StaticFallback(http.StatusServiceUnavailable, http.Header{"X-Fallback": {"true"}}, []byte("fallback"))),
+	)))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reply, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := reply.Text()
+	if reply.RawResponse().StatusCode != http.StatusServiceUnavailable ||
+		reply.RawResponse().Header.Get("X-Fallback") != "true" || body != "fallback" {
+		t.Fatalf("unexpected fallback reply %d %q", reply.RawResponse().StatusCode, body)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	registry := NewRegistry(WithIdleTimeout(time.Millisecond), WithCountWindow(2), WithFailureRate(0.5, 1))
+	registry.put(registry.get("idle"))
+	open := registry.get("open")
+	generation, _ := open.allow()
+	open.done(generation, true, false)
+	registry.put(open)
+	if open.State() != Open {
+		t.Fatalf("expected an open breaker, got %s", open.State())
+	}
+	busy := registry.get("busy")
+	time.Sleep(2 * time.Millisecond)
+	registry.put(registry.get("other"))
+
+	keys := make(map[string]bool)
+	for _, b := range registry.Breakers() {
+		keys[b.Key()] = true
+	}
+	if keys["idle"] {
+		t.Fatal("expected the idle breaker to be dropped")
+	}
+	if !keys["open"] || !keys["busy"] {
+		t.Fatalf("expected the open and busy breakers to be kept, got %v", keys)
+	}
+	registry.put(busy)
+}
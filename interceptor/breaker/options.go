@@ -0,0 +1,214 @@
+package easyhttpbreaker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// KeyFunc returns the key of a request, each key has its own breaker.
+type KeyFunc func(rawRequest *http.Request) string
+
+// ByHost breaks each host separately.
+func ByHost(rawRequest *http.Request) string {
+	return rawRequest.URL.Host
+}
+
+// ByRoute breaks each method, host and route separately, see easyhttp.RouteKey.
+func ByRoute(rawRequest *http.Request) string {
+	return easyhttp.RouteKey(rawRequest)
+}
+
+// Global shares a single breaker by every request.
+func Global(rawRequest *http.Request) string {
+	return ""
+}
+
+// Classifier reports whether the outcome of a call is a failure.
+type Classifier func(reply *easyhttp.Reply, err error) bool
+
+// DefaultClassifier treats errors, 5xx replies and calls without reply as failures, see easyhttp.IsFailure.
+// A StatusError of a 4xx reply and a canceled call are not failures.
+func DefaultClassifier(reply *easyhttp.Reply, err error) bool {
+	return easyhttp.IsFailure(reply, err)
+}
+
+// StatusClassifier treats errors and the given status codes as failures.
+func StatusClassifier(codes ...int) Classifier {
+	return func(reply *easyhttp.Reply, err error) bool {
+		statusCode := 0
+		var statusErr *easyhttp.StatusError
+		if errors.As(err, &statusErr) {
+			statusCode = statusErr.StatusCode
+		} else if err != nil {
+			return !errors.Is(err, context.Canceled)
+		} else if reply != nil && reply.RawResponse() != nil {
+			statusCode = reply.RawResponse().StatusCode
+		}
+		for _, code := range codes {
+			if code == statusCode {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Fallback returns the result of a call rejected by an open breaker, err is an *OpenError.
+type Fallback func(req *easyhttp.Request, err error) (*easyhttp.Reply, error)
+
+// StaticFallback synthesizes a reply with statusCode, header and body for the rejected calls.
+func StaticFallback(statusCode int, header http.Header, body []byte) Fallback {
+	return func(req *easyhttp.Request, err error) (*easyhttp.Reply, error) {
+		h := make(http.Header, len(header))
+		for key, values := range header {
+			h[key] = append([]string(nil), values...)
+		}
+		rawRequest := req.RawRequest()
+		response := &http.Response{
+			Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        h,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       rawRequest,
+		}
+		return easyhttp.NewReply(rawRequest, response), nil
+	}
+}
+
+type options struct {
+	keyFunc        KeyFunc
+	classifier     Classifier
+	windowSize     int
+	windowDuration time.Duration
+	windowBuckets  int
+	minimumCalls   int
+	failureRate    float64
+	slowCall       time.Duration
+	slowCallRate   float64
+	openTimeout    time.Duration
+	halfOpenProbes int
+	onStateChange  []func(key string, from, to State)
+	fallback       Fallback
+	idleTimeout    time.Duration
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		keyFunc:        ByHost,
+		classifier:     DefaultClassifier,
+		windowSize:     100,
+		minimumCalls:   20,
+		failureRate:    0.5,
+		openTimeout:    30 * time.Second,
+		halfOpenProbes: 1,
+		idleTimeout:    10 * time.Minute,
+	}
+}
+
+type Option func(o *options)
+
+// WithIdleTimeout drops the closed breaker of a key without calls for timeout, default is 10 minutes,
+// 0 keeps every breaker. A dropped key starts again with an empty window.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithKey sets the function returning the key of a request, default is ByHost.
+func WithKey(keyFunc KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = keyFunc
+	}
+}
+
+// WithClassifier sets the classifier of failures, default is DefaultClassifier.
+func WithClassifier(classifier Classifier) Option {
+	return func(o *options) {
+		o.classifier = classifier
+	}
+}
+
+// WithCountWindow computes the failure rate over the last size calls. It is the default, with 100 calls.
+func WithCountWindow(size int) Option {
+	return func(o *options) {
+		o.windowSize = size
+		o.windowDuration = 0
+	}
+}
+
+// WithTimeWindow computes the failure rate over the calls of the last duration, counted in buckets.
+func WithTimeWindow(duration time.Duration, buckets int) Option {
+	return func(o *options) {
+		o.windowDuration = duration
+		o.windowBuckets = buckets
+	}
+}
+
+// WithFailureRate opens the breaker when the failure rate (0-1) of the window reaches rate,
+// once the window has at least minimumCalls calls. Defaults are 0.5 and 20.
+func WithFailureRate(rate float64, minimumCalls int) Option {
+	return func(o *options) {
+		o.failureRate = rate
+		o.minimumCalls = minimumCalls
+	}
+}
+
+// WithSlowCall treats the calls slower than threshold as slow, and opens the breaker
+// when the rate (0-1) of slow calls of the window reaches rate.
+func WithSlowCall(threshold time.Duration, rate float64) Option {
+	return func(o *options) {
+		o.slowCall = threshold
+		o.slowCallRate = rate
+	}
+}
+
+// WithOpenTimeout sets the time the breaker stays open before probing, default is 30s.
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls let through when half-open,
+// the breaker closes if they all succeed and opens again on the first failure. Default is 1.
+func WithHalfOpenProbes(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = 1
+		}
+		o.halfOpenProbes = n
+	}
+}
+
+// WithOnStateChange adds hooks called when the state of a breaker changes.
+func WithOnStateChange(hooks ...func(key string, from, to State)) Option {
+	return func(o *options) {
+		o.onStateChange = append(o.onStateChange, hooks...)
+	}
+}
+
+// WithFallback sets the fallback of the calls rejected by an open breaker,
+// by default they fail with an *OpenError.
+func WithFallback(fallback Fallback) Option {
+	return func(o *options) {
+		o.fallback = fallback
+	}
+}
@@ -15,8 +15,15 @@ func Interceptor(Name string, opts ...Option) easyhttp.Interceptor {
 	apply(st, opts...)
 	cb := gobreaker.NewCircuitBreaker(*st)
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		var clientErr error
 		result, err := cb.Execute(func() (interface{}, error) {
 			reply, err = do(cli, req)
+			// a StatusError of client error status code is not a failure of the server
+			var statusErr *easyhttp.StatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode < http.StatusInternalServerError {
+				clientErr = err
+				return reply, nil
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -31,6 +38,9 @@ func Interceptor(Name string, opts ...Option) easyhttp.Interceptor {
 		if result != nil {
 			reply = result.(*easyhttp.Reply)
 		}
+		if err == nil && clientErr != nil {
+			return reply, clientErr
+		}
 		return reply, err
 	}
 }
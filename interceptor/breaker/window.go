@@ -0,0 +1,111 @@
+package easyhttpbreaker
+
+import "time"
+
+// window counts the outcome of the recent calls.
+type window interface {
+	record(now time.Time, failure, slow bool)
+	counts(now time.Time) (total, failures, slows int)
+	reset()
+}
+
+type outcome struct {
+	failure, slow bool
+}
+
+// countWindow keeps the outcome of the last size calls.
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	full     bool
+}
+
+func newCountWindow(size int) *countWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &countWindow{outcomes: make([]outcome, size)}
+}
+
+func (w *countWindow) record(now time.Time, failure, slow bool) {
+	w.outcomes[w.next] = outcome{failure: failure, slow: slow}
+	w.next++
+	if w.next == len(w.outcomes) {
+		w.next, w.full = 0, true
+	}
+}
+
+func (w *countWindow) counts(now time.Time) (total, failures, slows int) {
+	total = w.next
+	if w.full {
+		total = len(w.outcomes)
+	}
+	for _, o := range w.outcomes[:total] {
+		if o.failure {
+			failures++
+		}
+		if o.slow {
+			slows++
+		}
+	}
+	return total, failures, slows
+}
+
+func (w *countWindow) reset() {
+	w.next, w.full = 0, false
+}
+
+// timeWindow counts the calls of the last duration, in buckets.
+type timeWindow struct {
+	width   time.Duration
+	buckets []timeBucket
+}
+
+type timeBucket struct {
+	index                  int64
+	total, failures, slows int
+}
+
+func newTimeWindow(duration time.Duration, buckets int) *timeWindow {
+	if buckets < 1 {
+		buckets = 1
+	}
+	width := duration / time.Duration(buckets)
+	if width <= 0 {
+		width = time.Second
+	}
+	return &timeWindow{width: width, buckets: make([]timeBucket, buckets)}
+}
+
+func (w *timeWindow) record(now time.Time, failure, slow bool) {
+	index := now.UnixNano() / int64(w.width)
+	bucket := &w.buckets[index%int64(len(w.buckets))]
+	if bucket.index != index {
+		*bucket = timeBucket{index: index}
+	}
+	bucket.total++
+	if failure {
+		bucket.failures++
+	}
+	if slow {
+		bucket.slows++
+	}
+}
+
+func (w *timeWindow) counts(now time.Time) (total, failures, slows int) {
+	index := now.UnixNano() / int64(w.width)
+	for _, bucket := range w.buckets {
+		if bucket.index > index-int64(len(w.buckets)) {
+			total += bucket.total
+			failures += bucket.failures
+			slows += bucket.slows
+		}
+	}
+	return total, failures, slows
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = timeBucket{}
+	}
+}
@@ -0,0 +1,109 @@
+package easyhttpbulkhead
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+var (
+	// ErrRejected is matched by errors.Is for a *RejectedError.
+	ErrRejected = errors.New("rejected by bulkhead")
+
+	errQueueFull    = errors.New("queue full")
+	errQueueTimeout = errors.New("queue timeout")
+)
+
+// RejectedError is returned when a request is rejected because the limit of Key is reached.
+type RejectedError struct {
+	Key string
+	// Limit is the concurrency limit of Key when the request was rejected
+	Limit int
+	// Reason is "queue full" or "queue timeout"
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("easyhttpbulkhead: rejected %q, limit %d, %s", e.Key, e.Limit, e.Reason)
+}
+
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Interceptor bounds the in-flight requests, per key.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	b := &bulkheads{o: o, keys: make(map[string]*semaphore)}
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		key := o.keyFunc(req.RawRequest())
+		sem := b.get(key)
+		defer b.put(sem)
+		inflight, err := sem.acquire(req.Context(), o.maxQueue, o.queueTimeout)
+		if err != nil {
+			if err == errQueueFull || err == errQueueTimeout {
+				return nil, &RejectedError{Key: key, Limit: sem.limit.Limit(), Reason: err.Error()}
+			}
+			return nil, err
+		}
+		start := time.Now()
+		reply, err := do(cli, req)
+		rtt := time.Since(start)
+		sem.release()
+
+		statusCode := 0
+		var statusErr *easyhttp.StatusError
+		if errors.As(err, &statusErr) {
+			statusCode = statusErr.StatusCode
+		} else if reply != nil && reply.RawResponse() != nil {
+			statusCode = reply.RawResponse().StatusCode
+		}
+		sem.limit.OnSample(rtt, inflight, o.isDropped(statusCode, err))
+		return reply, err
+	}
+}
+
+type bulkheads struct {
+	o      *options
+	mu     sync.Mutex
+	keys   map[string]*semaphore
+	pruned time.Time
+}
+
+// get returns the semaphore of key, it must be given back with put.
+func (b *bulkheads) get(key string) *semaphore {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prune(time.Now())
+	sem, ok := b.keys[key]
+	if !ok {
+		sem = &semaphore{limit: b.o.newLimit()}
+		b.keys[key] = sem
+	}
+	sem.users++
+	return sem
+}
+
+func (b *bulkheads) put(sem *semaphore) {
+	b.mu.Lock()
+	sem.users--
+	sem.lastUsed = time.Now()
+	b.mu.Unlock()
+}
+
+// prune drops the semaphores without requests for the idle timeout, at most once per idle timeout.
+func (b *bulkheads) prune(now time.Time) {
+	if b.o.idleTimeout <= 0 || now.Sub(b.pruned) < b.o.idleTimeout {
+		return
+	}
+	b.pruned = now
+	for key, sem := range b.keys {
+		if sem.users == 0 && now.Sub(sem.lastUsed) >= b.o.idleTimeout {
+			delete(b.keys, key)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package easyhttpbulkhead
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestBulkhead(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(
+		WithMaxConcurrency(1),
+		WithQueue(1, 50*time.Millisecond),
+	)))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = client.Get(context.Background(), server.URL)
+	}()
+	<-started
+
+	// the queued request times out
+	_, err := client.Get(context.Background(), server.URL)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != "queue timeout" {
+		t.Fatalf("expected a queue timeout, got %v", err)
+	}
+
+	// the queued request is rejected when the queue is full, and runs once the slot is free
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrRejected) || !errors.As(err, &rejected) || rejected.Reason != "queue full" {
+		t.Fatalf("expected a full queue, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestIdleTimeout(t *testing.T) {
+	o := defaultOptions()
+	o.apply(WithIdleTimeout(time.Millisecond))
+	b := &bulkheads{o: o, keys: make(map[string]*semaphore)}
+	idle := b.get("idle")
+	b.put(idle)
+	busy := b.get("busy")
+	time.Sleep(2 * time.Millisecond)
+	b.put(b.get("other"))
+	if _, ok := b.keys["idle"]; ok {
+		t.Fatal("expected the idle key to be dropped")
+	}
+	if b.keys["busy"] != busy {
+		t.Fatal("expected the key in use to be kept")
+	}
+}
+
+func TestDefaultIsDropped(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		err        error
+		dropped    bool
+	}{
+		{statusCode: http.StatusOK},
+		{statusCode: http.StatusServiceUnavailable, dropped: true},
+		{err: errors.New("connection refused"), dropped: true},
+		{err: context.Canceled},
+		{err: &easyhttp.StatusError{StatusCode: http.StatusNotFound}},
+		{err: &easyhttp.StatusError{StatusCode: http.StatusInternalServerError}},
+		{err: &easyhttp.StatusError{StatusCode: http.StatusTooManyRequests}, dropped: true},
+	}
+	for _, c := range cases {
+		if dropped := defaultIsDropped(c.statusCode, c.err); dropped != c.dropped {
+			t.Errorf("defaultIsDropped(%d, %v) = %t", c.statusCode, c.err, dropped)
+		}
+	}
+}
+
+func TestAIMDLimit(t *testing.T) {
+	limit := NewAIMDLimit(10, 1, 20, 0.5, 100*time.Millisecond)
+	limit.OnSample(10*time.Millisecond, 5, false)
+	if limit.Limit() != 11 {
+		t.Fatalf("unexpected limit %d", limit.Limit())
+	}
+	limit.OnSample(10*time.Millisecond, 1, false)
+	if limit.Limit() != 11 {
+		t.Fatalf("an underused limit should not grow, got %d", limit.Limit())
+	}
+	limit.OnSample(200*time.Millisecond, 5, false)
+	if limit.Limit() != 5 {
+		t.Fatalf("unexpected limit %d", limit.Limit())
+	}
+}
+
+func TestVegasLimit(t *testing.T) {
+	limit := NewVegasLimit(10, 1, 100)
+	limit.OnSample(10*time.Millisecond, 10, false)
+	if limit.Limit() <= 10 {
+		t.Fatalf("expected the limit to grow, got %d", limit.Limit())
+	}
+	before := limit.Limit()
+	// the latency tripled, the queue is large
+	limit.OnSample(30*time.Millisecond, before, false)
+	if limit.Limit() >= before {
+		t.Fatalf("expected the limit to shrink, got %d", limit.Limit())
+	}
+}
+
+func TestGradientLimit(t *testing.T) {
+	limit := NewGradientLimit(16, 1, 100, 1)
+	limit.OnSample(10*time.Millisecond, 16, false)
+	if limit.Limit() != 20 {
+		t.Fatalf("unexpected limit %d", limit.Limit())
+	}
+	limit.OnSample(10*time.Millisecond, 20, true)
+	if limit.Limit() >= 20 {
+		t.Fatalf("expected the limit to shrink, got %d", limit.Limit())
+	}
+}
@@ -0,0 +1,163 @@
+package easyhttpbulkhead
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the concurrency limit of a key, it may adapt to the samples of the calls.
+type Limit interface {
+	// Limit returns the current maximum number of in-flight requests.
+	Limit() int
+	// OnSample is called at the end of each call, with its latency, the number of in-flight requests
+	// when it started and whether it was dropped (failed or overloaded).
+	OnSample(rtt time.Duration, inflight int, dropped bool)
+}
+
+// FixedLimit is a Limit that never changes.
+type FixedLimit int
+
+func (l FixedLimit) Limit() int {
+	return int(l)
+}
+
+func (l FixedLimit) OnSample(rtt time.Duration, inflight int, dropped bool) {}
+
+// AIMDLimit increases the limit by one on each successful call using at least half of the limit,
+// and multiplies it by backoffRatio on each dropped call or call slower than timeout.
+type AIMDLimit struct {
+	min, max     int
+	backoffRatio float64
+	timeout      time.Duration
+
+	mu    sync.Mutex
+	limit float64
+}
+
+// NewAIMDLimit creates an AIMDLimit, timeout 0 disables the latency threshold.
+func NewAIMDLimit(initial, min, max int, backoffRatio float64, timeout time.Duration) *AIMDLimit {
+	return &AIMDLimit{min: min, max: max, backoffRatio: backoffRatio, timeout: timeout, limit: float64(initial)}
+}
+
+func (l *AIMDLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *AIMDLimit) OnSample(rtt time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if dropped || (l.timeout > 0 && rtt > l.timeout) {
+		l.limit = l.limit * l.backoffRatio
+	} else if float64(inflight)*2 >= l.limit {
+		l.limit++
+	}
+	l.limit = clamp(l.limit, l.min, l.max)
+}
+
+// VegasLimit estimates the queue of the server from the ratio of the minimum latency to the latency
+// of a call, it increases the limit while the queue is small and decreases it when the queue grows.
+type VegasLimit struct {
+	min, max int
+
+	mu        sync.Mutex
+	limit     float64
+	rttNoLoad time.Duration
+}
+
+func NewVegasLimit(initial, min, max int) *VegasLimit {
+	return &VegasLimit{min: min, max: max, limit: float64(initial)}
+}
+
+func (l *VegasLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *VegasLimit) OnSample(rtt time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rtt <= 0 {
+		return
+	}
+	if l.rttNoLoad == 0 || rtt < l.rttNoLoad {
+		l.rttNoLoad = rtt
+	}
+	step := math.Max(1, math.Log10(l.limit))
+	if dropped {
+		l.limit = clamp(l.limit-step, l.min, l.max)
+		return
+	}
+	// only probe a higher limit if it is used
+	if float64(inflight)*2 < l.limit {
+		return
+	}
+	queue := math.Ceil(l.limit * (1 - float64(l.rttNoLoad)/float64(rtt)))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case queue <= alpha:
+		l.limit += step
+	case queue >= beta:
+		l.limit -= step
+	}
+	l.limit = clamp(l.limit, l.min, l.max)
+}
+
+// GradientLimit adjusts the limit by the gradient of a long term average latency to the latency of a call,
+// allowing a queue of the square root of the limit.
+type GradientLimit struct {
+	min, max  int
+	smoothing float64
+
+	mu      sync.Mutex
+	limit   float64
+	longRtt float64
+}
+
+// NewGradientLimit creates a GradientLimit, smoothing (0-1] is the weight of a new limit, e.g. 0.2.
+func NewGradientLimit(initial, min, max int, smoothing float64) *GradientLimit {
+	return &GradientLimit{min: min, max: max, smoothing: smoothing, limit: float64(initial)}
+}
+
+func (l *GradientLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *GradientLimit) OnSample(rtt time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rtt <= 0 {
+		return
+	}
+	shortRtt := float64(rtt)
+	if l.longRtt == 0 {
+		l.longRtt = shortRtt
+	} else {
+		l.longRtt = l.longRtt*0.95 + shortRtt*0.05
+	}
+	// an underused limit is not raised
+	if !dropped && float64(inflight)*2 < l.limit {
+		return
+	}
+	gradient := math.Max(0.5, math.Min(1, l.longRtt/shortRtt))
+	if dropped {
+		gradient = 0.5
+	}
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = clamp(l.limit*(1-l.smoothing)+newLimit*l.smoothing, l.min, l.max)
+}
+
+func clamp(limit float64, min, max int) float64 {
+	if limit < float64(min) {
+		return float64(min)
+	}
+	if max > 0 && limit > float64(max) {
+		return float64(max)
+	}
+	return limit
+}
@@ -0,0 +1,108 @@
+package easyhttpbulkhead
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// KeyFunc returns the key of a request, each key has its own bulkhead.
+type KeyFunc func(rawRequest *http.Request) string
+
+// ByHost isolates each host.
+func ByHost(rawRequest *http.Request) string {
+	return rawRequest.URL.Host
+}
+
+// ByRoute isolates each method, host and route separately, see easyhttp.RouteKey.
+func ByRoute(rawRequest *http.Request) string {
+	return easyhttp.RouteKey(rawRequest)
+}
+
+type options struct {
+	newLimit     func() Limit
+	keyFunc      KeyFunc
+	maxQueue     int
+	queueTimeout time.Duration
+	isDropped    func(statusCode int, err error) bool
+	idleTimeout  time.Duration
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		newLimit:    func() Limit { return FixedLimit(100) },
+		keyFunc:     ByHost,
+		isDropped:   defaultIsDropped,
+		idleTimeout: 10 * time.Minute,
+	}
+}
+
+type Option func(o *options)
+
+// WithMaxConcurrency sets a fixed limit of in-flight requests per key. Default is 100.
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) {
+		o.newLimit = func() Limit { return FixedLimit(n) }
+	}
+}
+
+// WithLimit sets the factory of the Limit of each key, e.g. an adaptive limit:
+//
+//	WithLimit(func() Limit { return NewVegasLimit(20, 1, 200) })
+func WithLimit(newLimit func() Limit) Option {
+	return func(o *options) {
+		o.newLimit = newLimit
+	}
+}
+
+// WithQueue lets up to maxQueue requests per key wait for a slot, for at most timeout (0 means no timeout).
+// By default requests are rejected as soon as the limit is reached.
+func WithQueue(maxQueue int, timeout time.Duration) Option {
+	return func(o *options) {
+		o.maxQueue = maxQueue
+		o.queueTimeout = timeout
+	}
+}
+
+// WithKey sets the function returning the key of a request, default is ByHost.
+func WithKey(keyFunc KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = keyFunc
+	}
+}
+
+// WithIdleTimeout drops the bulkhead of a key without requests for timeout, default is 10 minutes,
+// 0 keeps every bulkhead. A dropped key starts again from the initial limit.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithDropped sets the function deciding whether a call is dropped for the adaptive limits,
+// statusCode is 0 if there is no reply. By default errors, except canceled calls and status errors, and 429, 503 statuses are dropped.
+func WithDropped(isDropped func(statusCode int, err error) bool) Option {
+	return func(o *options) {
+		o.isDropped = isDropped
+	}
+}
+
+func defaultIsDropped(statusCode int, err error) bool {
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		statusCode, err = statusErr.StatusCode, nil
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return err != nil || statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
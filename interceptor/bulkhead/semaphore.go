@@ -0,0 +1,94 @@
+package easyhttpbulkhead
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// semaphore bounds the in-flight requests of a key to its limit, with a bounded FIFO queue.
+type semaphore struct {
+	limit Limit
+	// users and lastUsed are guarded by bulkheads.mu
+	users    int
+	lastUsed time.Time
+
+	mu       sync.Mutex
+	inflight int
+	waiters  []*waiter
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// acquire takes a slot, waiting in the queue if there is room in it, up to timeout (0 means no timeout).
+// It returns the number of in-flight requests when the slot is taken.
+func (s *semaphore) acquire(ctx context.Context, maxQueue int, timeout time.Duration) (int, error) {
+	s.mu.Lock()
+	if s.inflight < s.limit.Limit() && len(s.waiters) == 0 {
+		s.inflight++
+		inflight := s.inflight
+		s.mu.Unlock()
+		return inflight, nil
+	}
+	if len(s.waiters) >= maxQueue {
+		s.mu.Unlock()
+		return 0, errQueueFull
+	}
+	w := &waiter{ready: make(chan struct{})}
+	s.waiters = append(s.waiters, w)
+	s.mu.Unlock()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	var err error
+	select {
+	case <-w.ready:
+	case <-timeoutC:
+		err = errQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.granted {
+		if err == nil {
+			return s.inflight, nil
+		}
+		// granted while giving up, hand the slot to the next waiter
+		s.inflight--
+		s.grant()
+		return 0, err
+	}
+	for i, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			break
+		}
+	}
+	return 0, err
+}
+
+func (s *semaphore) release() {
+	s.mu.Lock()
+	s.inflight--
+	s.grant()
+	s.mu.Unlock()
+}
+
+// grant gives the free slots to the waiters in order, s.mu must be held.
+func (s *semaphore) grant() {
+	for len(s.waiters) > 0 && s.inflight < s.limit.Limit() {
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		w.granted = true
+		s.inflight++
+		close(w.ready)
+	}
+}
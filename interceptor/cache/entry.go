@@ -0,0 +1,179 @@
+package easyhttpcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entry is a cached response.
+type entry struct {
+	StatusCode   int
+	Status       string
+	Proto        string
+	ProtoMajor   int
+	ProtoMinor   int
+	Header       http.Header
+	Body         []byte
+	RequestTime  time.Time
+	ResponseTime time.Time
+	// Vary holds the values of the request headers nominated by the Vary header of the response
+	Vary map[string]string
+}
+
+func decodeEntry(data []byte) (*entry, error) {
+	e := &entry{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *entry) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// matches reports whether the request selects this entry, according to Vary.
+func (e *entry) matches(rawRequest *http.Request) bool {
+	for field, value := range e.Vary {
+		if strings.Join(rawRequest.Header.Values(field), ",") != value {
+			return false
+		}
+	}
+	return true
+}
+
+// response builds the response served from the entry, with its Age header.
+func (e *entry) response(rawRequest *http.Request, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         e.Proto,
+		ProtoMajor:    e.ProtoMajor,
+		ProtoMinor:    e.ProtoMinor,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       rawRequest,
+	}
+}
+
+// age is the current age of the response, RFC 9111 section 4.2.3.
+func (e *entry) age(now time.Time) time.Duration {
+	var apparentAge time.Duration
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		if d := e.ResponseTime.Sub(date); d > 0 {
+			apparentAge = d
+		}
+	}
+	responseDelay := e.ResponseTime.Sub(e.RequestTime)
+	correctedAge := responseDelay
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		correctedAge += time.Duration(seconds) * time.Second
+	}
+	initialAge := apparentAge
+	if correctedAge > initialAge {
+		initialAge = correctedAge
+	}
+	return initialAge + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime is the time the response is fresh, RFC 9111 section 4.2.1.
+func (e *entry) freshnessLifetime() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if maxAge, ok := cc.duration("max-age"); ok {
+		return maxAge
+	}
+	date, dateErr := http.ParseTime(e.Header.Get("Date"))
+	if dateErr != nil {
+		date = e.ResponseTime
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		// an invalid Expires means already expired
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	// heuristic freshness, 10% of the time since the last modification
+	if isHeuristicallyCacheable(e.StatusCode) {
+		if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+			return date.Sub(lastModified) / 10
+		}
+	}
+	return 0
+}
+
+// update merges the headers of a 304 response into the entry, RFC 9111 section 4.3.4.
+func (e *entry) update(response *http.Response, requestTime, responseTime time.Time) {
+	for key, values := range response.Header {
+		switch key {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding", "Content-Range":
+			continue
+		}
+		e.Header[key] = values
+	}
+	e.RequestTime = requestTime
+	e.ResponseTime = responseTime
+}
+
+// isHeuristicallyCacheable reports whether a status code is cacheable by default, RFC 9110 section 15.1.
+func isHeuristicallyCacheable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent, http.StatusMultipleChoices,
+		http.StatusMovedPermanently, http.StatusPermanentRedirect, http.StatusNotFound, http.StatusMethodNotAllowed,
+		http.StatusGone, http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// cacheControl holds the directives of the Cache-Control headers.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// duration returns the value in seconds of a directive.
+func (cc cacheControl) duration(name string) (time.Duration, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
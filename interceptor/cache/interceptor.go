@@ -0,0 +1,351 @@
+package easyhttpcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Interceptor is a private HTTP cache following RFC 9111.
+//
+// GET responses are stored according to their Cache-Control and Expires headers, served while fresh,
+// and revalidated with their ETag and Last-Modified validators once stale.
+// The stale-while-revalidate and stale-if-error extensions (RFC 5861) are supported.
+// Unsafe requests invalidate the cached response of their url.
+// The replies served from the cache are flagged, see easyhttp.Reply.CacheStatus.
+//
+// The cache runs innermost, see easyhttp.Innermost, so it is keyed by the final request, after the
+// per-call path params, query and headers. The responses of different credentials are never shared:
+// the Authorization header of the request is part of the key.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	c := &cache{o: o, revalidating: make(map[string]struct{})}
+	return easyhttp.Innermost(c.intercept)
+}
+
+type cache struct {
+	o *options
+
+	mu           sync.Mutex
+	revalidating map[string]struct{}
+}
+
+func (c *cache) intercept(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+	rawRequest := req.RawRequest()
+	key := cacheKey(rawRequest)
+	if rawRequest.Method != http.MethodGet && rawRequest.Method != "" {
+		reply, err := do(cli, req)
+		if err == nil && isUnsafe(rawRequest.Method) && reply != nil && reply.RawResponse() != nil &&
+			reply.RawResponse().StatusCode < http.StatusBadRequest {
+			c.o.store.Delete(key)
+		}
+		return reply, err
+	}
+	// partial and conditional requests of the caller are passed through
+	if rawRequest.Header.Get("Range") != "" || hasConditional(rawRequest.Header) {
+		return do(cli, req)
+	}
+
+	reqCC := parseCacheControl(rawRequest.Header)
+	now := time.Now()
+	e := c.load(key, rawRequest)
+	if e != nil {
+		respCC := parseCacheControl(e.Header)
+		age, lifetime := e.age(now), e.freshnessLifetime()
+		noCache := reqCC.has("no-cache") || respCC.has("no-cache") || rawRequest.Header.Get("Pragma") == "no-cache"
+		if !noCache {
+			if maxAge, ok := reqCC.duration("max-age"); ok && maxAge < lifetime {
+				lifetime = maxAge
+			}
+			if minFresh, ok := reqCC.duration("min-fresh"); ok {
+				lifetime -= minFresh
+			}
+			if age < lifetime {
+				return c.serve(e, rawRequest, now, easyhttp.CacheHit), nil
+			}
+			staleness := age - lifetime
+			mustRevalidate := respCC.has("must-revalidate")
+			if !mustRevalidate && reqCC.has("max-stale") {
+				if maxStale, ok := reqCC.duration("max-stale"); !ok || staleness <= maxStale {
+					return c.serve(e, rawRequest, now, easyhttp.CacheStale), nil
+				}
+			}
+			if swr, ok := respCC.duration("stale-while-revalidate"); ok && !mustRevalidate && staleness <= swr {
+				reply := c.serve(e, rawRequest, now, easyhttp.CacheStale)
+				c.revalidate(cli, req, do, key, e)
+				return reply, nil
+			}
+		}
+	}
+	if reqCC.has("only-if-cached") {
+		return gatewayTimeout(rawRequest), nil
+	}
+
+	if e != nil {
+		setConditional(rawRequest.Header, e)
+	}
+	requestTime := time.Now()
+	reply, err := do(cli, req)
+	responseTime := time.Now()
+	if e != nil {
+		removeConditional(rawRequest.Header)
+	}
+
+	if e != nil && c.canServeOnError(e, reqCC, reply, err, responseTime) {
+		discard(reply)
+		return c.serve(e, rawRequest, responseTime, easyhttp.CacheStale), nil
+	}
+	if err != nil || reply == nil || reply.RawResponse() == nil {
+		return reply, err
+	}
+	response := reply.RawResponse()
+	if e != nil && response.StatusCode == http.StatusNotModified {
+		discard(reply)
+		e.update(response, requestTime, responseTime)
+		c.save(key, e)
+		return c.serve(e, rawRequest, responseTime, easyhttp.CacheRevalidated), nil
+	}
+	c.store(key, rawRequest, reqCC, response, requestTime, responseTime)
+	return reply, nil
+}
+
+// revalidate revalidates the entry in the background, one revalidation at a time per key.
+func (c *cache) revalidate(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer, key string, e *entry) {
+	c.mu.Lock()
+	if _, ok := c.revalidating[key]; ok {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = struct{}{}
+	c.mu.Unlock()
+
+	// the caller does not wait for the revalidation, it must outlive the context of the caller
+	background := req.Clone(context.Background())
+	setConditional(background.RawRequest().Header, e)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+		rawRequest := background.RawRequest()
+		requestTime := time.Now()
+		reply, err := do(cli, background)
+		responseTime := time.Now()
+		if err != nil || reply == nil || reply.RawResponse() == nil {
+			return
+		}
+		response := reply.RawResponse()
+		if response.StatusCode == http.StatusNotModified {
+			discard(reply)
+			e.update(response, requestTime, responseTime)
+			c.save(key, e)
+			return
+		}
+		removeConditional(rawRequest.Header)
+		c.store(key, rawRequest, parseCacheControl(rawRequest.Header), response, requestTime, responseTime)
+		discard(reply)
+	}()
+}
+
+// canServeOnError reports whether the stale entry can be served because the server failed, RFC 5861 section 4.
+func (c *cache) canServeOnError(e *entry, reqCC cacheControl, reply *easyhttp.Reply, err error, now time.Time) bool {
+	if err == nil && (reply == nil || reply.RawResponse() == nil || reply.RawResponse().StatusCode < http.StatusInternalServerError) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	respCC := parseCacheControl(e.Header)
+	if respCC.has("must-revalidate") {
+		return false
+	}
+	staleness := e.age(now) - e.freshnessLifetime()
+	for _, cc := range []cacheControl{reqCC, respCC} {
+		if sie, ok := cc.duration("stale-if-error"); ok && staleness <= sie {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cache) serve(e *entry, rawRequest *http.Request, now time.Time, status easyhttp.CacheStatus) *easyhttp.Reply {
+	reply := easyhttp.NewReply(rawRequest, e.response(rawRequest, now))
+	reply.SetCacheStatus(status)
+	return reply
+}
+
+func (c *cache) load(key string, rawRequest *http.Request) *entry {
+	data, ok := c.o.store.Get(key)
+	if !ok {
+		return nil
+	}
+	e, err := decodeEntry(data)
+	if err != nil {
+		c.o.store.Delete(key)
+		return nil
+	}
+	if !e.matches(rawRequest) {
+		return nil
+	}
+	return e
+}
+
+func (c *cache) save(key string, e *entry) {
+	data, err := e.encode()
+	if err != nil {
+		return
+	}
+	c.o.store.Set(key, data)
+}
+
+// store stores the response if it is storable, RFC 9111 section 3.
+// The body is buffered and the response body replaced, so the caller can still read it.
+func (c *cache) store(key string, rawRequest *http.Request, reqCC cacheControl, response *http.Response,
+	requestTime, responseTime time.Time) {
+	respCC := parseCacheControl(response.Header)
+	if reqCC.has("no-store") || respCC.has("no-store") {
+		return
+	}
+	explicit := respCC.has("max-age") || response.Header.Get("Expires") != "" || respCC.has("public")
+	if !explicit && !isHeuristicallyCacheable(response.StatusCode) {
+		return
+	}
+	if response.StatusCode == http.StatusPartialContent || response.StatusCode < http.StatusOK {
+		return
+	}
+	vary := make(map[string]string)
+	for _, value := range response.Header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = http.CanonicalHeaderKey(strings.TrimSpace(field))
+			if field == "*" {
+				return
+			}
+			if field != "" {
+				vary[field] = strings.Join(rawRequest.Header.Values(field), ",")
+			}
+		}
+	}
+	if response.ContentLength > c.o.maxEntrySize {
+		return
+	}
+	body, complete, err := readLimited(response.Body, c.o.maxEntrySize)
+	if err != nil {
+		response.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return
+	}
+	if !complete {
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+		return
+	}
+	_ = response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.save(key, &entry{
+		StatusCode:   response.StatusCode,
+		Status:       response.Status,
+		Proto:        response.Proto,
+		ProtoMajor:   response.ProtoMajor,
+		ProtoMinor:   response.ProtoMinor,
+		Header:       response.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		Vary:         vary,
+	})
+}
+
+// readLimited reads body up to limit bytes, complete is false if the body is larger.
+func readLimited(body io.Reader, limit int64) (data []byte, complete bool, err error) {
+	data, err = ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return data, false, err
+	}
+	if int64(len(data)) > limit {
+		return data, false, nil
+	}
+	return data, true, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// cacheKey is the url of the request, and the hash of its credentials if it has some,
+// so they are not written in the store.
+func cacheKey(rawRequest *http.Request) string {
+	authorization := rawRequest.Header.Get("Authorization")
+	if authorization == "" {
+		return rawRequest.URL.String()
+	}
+	sum := sha256.Sum256([]byte(authorization))
+	return rawRequest.URL.String() + " " + hex.EncodeToString(sum[:])
+}
+
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+func hasConditional(header http.Header) bool {
+	return header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != "" ||
+		header.Get("If-Match") != "" || header.Get("If-Unmodified-Since") != ""
+}
+
+func setConditional(header http.Header, e *entry) {
+	if etag := e.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+func removeConditional(header http.Header) {
+	header.Del("If-None-Match")
+	header.Del("If-Modified-Since")
+}
+
+// gatewayTimeout is the reply of an only-if-cached request without a usable cached response.
+func gatewayTimeout(rawRequest *http.Request) *easyhttp.Reply {
+	return easyhttp.NewReply(rawRequest, &http.Response{
+		Status:     "504 " + http.StatusText(http.StatusGatewayTimeout),
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    rawRequest,
+	})
+}
+
+// discard drains and closes the body of a reply that is not returned.
+func discard(reply *easyhttp.Reply) {
+	if reply == nil || reply.RawResponse() == nil || reply.RawResponse().Body == nil {
+		return
+	}
+	body := reply.RawResponse().Body
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, 64<<10))
+	_ = body.Close()
+}
@@ -0,0 +1,210 @@
+package easyhttpcache
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// get sends a GET request with the header key values through cache.
+func get(t *testing.T, cache easyhttp.Interceptor, url string, header ...string) (*easyhttp.Reply, string) {
+	setHeader := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		for i := 0; i+1 < len(header); i += 2 {
+			req.RawRequest().Header.Set(header[i], header[i+1])
+		}
+		return do(cli, req)
+	}
+	reply, err := easyhttp.NewClient().Get(context.Background(), url, setHeader, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := reply.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply, body
+}
+
+func TestFreshness(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	cache := Interceptor()
+	reply, body := get(t, cache, server.URL)
+	if reply.CacheStatus() != easyhttp.CacheMiss || body != "catalog" {
+		t.Fatalf("unexpected reply %s %q", reply.CacheStatus(), body)
+	}
+	reply, body = get(t, cache, server.URL)
+	if reply.CacheStatus() != easyhttp.CacheHit || body != "catalog" {
+		t.Fatalf("unexpected reply %s %q", reply.CacheStatus(), body)
+	}
+	reply, _ = get(t, cache, server.URL, "Cache-Control", "no-cache")
+	if reply.CacheStatus() != easyhttp.CacheMiss || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected no-cache to reach the server, got %s", reply.CacheStatus())
+	}
+
+	// an unsafe request invalidates the url
+	if _, err := easyhttp.NewClient().Post(context.Background(), server.URL, cache); err != nil {
+		t.Fatal(err)
+	}
+	reply, _ = get(t, cache, server.URL)
+	if reply.CacheStatus() != easyhttp.CacheMiss {
+		t.Fatalf("expected a miss after invalidation, got %s", reply.CacheStatus())
+	}
+}
+
+func TestPerCallRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor()))
+	statuses := []easyhttp.CacheStatus{easyhttp.CacheMiss, easyhttp.CacheMiss, easyhttp.CacheHit}
+	for i, who := range []string{"alice", "bob", "alice"} {
+		reply, err := client.R().PathParam("id", who).BearerToken(who).Get(context.Background(), server.URL+"/users/:id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := reply.Text(); body != "/users/"+who+" Bearer "+who || reply.CacheStatus() != statuses[i] {
+			t.Fatalf("%s got %q, %s", who, body, reply.CacheStatus())
+		}
+	}
+	// another user on the same url is not served from the cache
+	reply, err := client.R().PathParam("id", "alice").BearerToken("bob").Get(context.Background(), server.URL+"/users/:id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := reply.Text(); reply.CacheStatus() != easyhttp.CacheMiss || body != "/users/alice Bearer bob" {
+		t.Fatalf("unexpected reply %s %q", reply.CacheStatus(), body)
+	}
+}
+
+func TestRevalidation(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("config"))
+	}))
+	defer server.Close()
+
+	cache := Interceptor()
+	get(t, cache, server.URL)
+	reply, body := get(t, cache, server.URL)
+	if reply.CacheStatus() != easyhttp.CacheRevalidated || body != "config" || reply.RawResponse().StatusCode != http.StatusOK {
+		t.Fatalf("unexpected reply %s %d %q", reply.CacheStatus(), reply.RawResponse().StatusCode, body)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("unexpected calls %d", calls)
+	}
+}
+
+func TestVary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	cache := Interceptor()
+	get(t, cache, server.URL, "Accept-Language", "en")
+	reply, body := get(t, cache, server.URL, "Accept-Language", "fr")
+	if reply.CacheStatus() != easyhttp.CacheMiss || body != "fr" {
+		t.Fatalf("unexpected reply %s %q", reply.CacheStatus(), body)
+	}
+}
+
+func TestStale(t *testing.T) {
+	var fail int32
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+		w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	cache := Interceptor()
+	get(t, cache, server.URL)
+	atomic.StoreInt32(&fail, 1)
+	reply, body := get(t, cache, server.URL)
+	if reply.CacheStatus() != easyhttp.CacheStale || body != "catalog" {
+		t.Fatalf("unexpected reply %s %q", reply.CacheStatus(), body)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		w.Write([]byte("catalog"))
+	})
+	get(t, cache, server.URL+"/swr")
+	before := atomic.LoadInt32(&calls)
+	reply, _ = get(t, cache, server.URL+"/swr")
+	if reply.CacheStatus() != easyhttp.CacheStale {
+		t.Fatalf("unexpected reply %s", reply.CacheStatus())
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) == before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&calls) == before {
+		t.Fatal("expected a background revalidation")
+	}
+}
+
+func TestStores(t *testing.T) {
+	memory := NewMemoryStore(10)
+	memory.Set("a", []byte("12345"))
+	memory.Set("b", []byte("12345"))
+	memory.Get("a")
+	memory.Set("c", []byte("12345"))
+	if _, ok := memory.Get("b"); ok {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+	if _, ok := memory.Get("a"); !ok {
+		t.Fatal("expected a to be kept")
+	}
+
+	dir, err := ioutil.TempDir("", "easyhttp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	disk, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk.Set("a", []byte("12345"))
+	if data, ok := disk.Get("a"); !ok || string(data) != "12345" {
+		t.Fatalf("unexpected entry %q", data)
+	}
+	disk.Delete("a")
+	if _, ok := disk.Get("a"); ok {
+		t.Fatal("expected the entry to be deleted")
+	}
+}
@@ -0,0 +1,36 @@
+package easyhttpcache
+
+type options struct {
+	store        Store
+	maxEntrySize int64
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		store:        NewMemoryStore(32 << 20),
+		maxEntrySize: 1 << 20,
+	}
+}
+
+type Option func(o *options)
+
+// WithStore sets the Store of the cached responses, default is a MemoryStore of 32MB.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithMaxEntrySize sets the maximum size of a cached response body, larger responses are not stored.
+// Default is 1MB.
+func WithMaxEntrySize(size int64) Option {
+	return func(o *options) {
+		o.maxEntrySize = size
+	}
+}
@@ -0,0 +1,75 @@
+package easyhttpcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Store stores the cached responses, encoded, by key.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, data []byte)
+	Delete(key string)
+}
+
+// MemoryStore is an in-memory LRU Store bounded by the total size of its entries.
+type MemoryStore struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxBytes, the least recently used entries are evicted.
+func NewMemoryStore(maxBytes int64) *MemoryStore {
+	return &MemoryStore{maxBytes: maxBytes, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+func (s *MemoryStore) Set(key string, data []byte) {
+	if int64(len(data)) > s.maxBytes {
+		s.Delete(key)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, data: data})
+	s.size += int64(len(data))
+	for s.size > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+}
+
+func (s *MemoryStore) remove(elem *list.Element) {
+	entry := s.lru.Remove(elem).(*memoryEntry)
+	delete(s.entries, entry.key)
+	s.size -= int64(len(entry.data))
+}
@@ -0,0 +1,58 @@
+package easyhttpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DiskStore is a Store keeping each entry in a file of a directory.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a DiskStore in dir, which is created if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes the entry to a temporary file renamed once complete, so readers never see a partial entry.
+func (s *DiskStore) Set(key string, data []byte) {
+	file, err := ioutil.TempFile(s.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return
+	}
+	if err := os.Rename(file.Name(), s.path(key)); err != nil {
+		_ = os.Remove(file.Name())
+	}
+}
+
+func (s *DiskStore) Delete(key string) {
+	_ = os.Remove(s.path(key))
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
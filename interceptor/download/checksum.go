@@ -0,0 +1,79 @@
+package easyhttpdownload
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/soyacen/goutils/ioutils"
+)
+
+var (
+	kDigestKey     = http.CanonicalHeaderKey("Digest")
+	kContentMD5Key = http.CanonicalHeaderKey("Content-MD5")
+)
+
+// headerChecksums returns the checksums announced by the response headers.
+// Content-MD5 describes the body, so it is used for complete responses only.
+func headerChecksums(rawResponse *http.Response) []checksum {
+	var checksums []checksum
+	for _, digest := range strings.Split(rawResponse.Header.Get(kDigestKey), ",") {
+		i := strings.Index(digest, "=")
+		if i < 0 {
+			continue
+		}
+		var newHash func() hash.Hash
+		switch algorithm := strings.TrimSpace(digest[:i]); {
+		case strings.EqualFold(algorithm, "SHA-256"):
+			newHash = sha256.New
+		case strings.EqualFold(algorithm, "MD5"):
+			newHash = md5.New
+		default:
+			continue
+		}
+		if expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(digest[i+1:])); err == nil {
+			checksums = append(checksums, checksum{name: "Digest " + digest[:i], newHash: newHash, expected: expected})
+		}
+	}
+	if contentMD5 := rawResponse.Header.Get(kContentMD5Key); contentMD5 != "" && rawResponse.StatusCode == http.StatusOK {
+		if expected, err := base64.StdEncoding.DecodeString(contentMD5); err == nil {
+			checksums = append(checksums, checksum{name: "Content-MD5", newHash: md5.New, expected: expected})
+		}
+	}
+	return checksums
+}
+
+// verify checks the file at path against all checksums.
+func verify(path string, checksums []checksum) (err error) {
+	if len(checksums) == 0 {
+		return nil
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer ioutils.CloseThrowError(fd, &err)
+	hashes := make([]hash.Hash, 0, len(checksums))
+	writers := make([]io.Writer, 0, len(checksums))
+	for _, c := range checksums {
+		h := c.newHash()
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+	if _, err = io.Copy(io.MultiWriter(writers...), fd); err != nil {
+		return err
+	}
+	for i, c := range checksums {
+		if actual := hashes[i].Sum(nil); !bytes.Equal(actual, c.expected) {
+			return fmt.Errorf("easyhttpdownload: %s mismatch, expected %x, actual %x", c.name, c.expected, actual)
+		}
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package easyhttpdownload
 
 import (
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	filepathutils "path/filepath"
@@ -14,64 +17,242 @@ import (
 )
 
 var (
-	kContentTypeKey     = http.CanonicalHeaderKey("Content-Type")
-	kAcceptKey          = http.CanonicalHeaderKey("Accept")
 	kContentEncodingKey = http.CanonicalHeaderKey("Content-Encoding")
+	kAcceptEncodingKey  = http.CanonicalHeaderKey("Accept-Encoding")
+	kAcceptRangesKey    = http.CanonicalHeaderKey("Accept-Ranges")
+	kContentRangeKey    = http.CanonicalHeaderKey("Content-Range")
+	kRangeKey           = http.CanonicalHeaderKey("Range")
+	kIfRangeKey         = http.CanonicalHeaderKey("If-Range")
+	kETagKey            = http.CanonicalHeaderKey("ETag")
+	kLastModifiedKey    = http.CanonicalHeaderKey("Last-Modified")
 )
 
-func Interceptor(filepath string) easyhttp.Interceptor {
+const (
+	kPartSuffix = ".part"
+	kMetaSuffix = ".part.meta"
+)
+
+// Interceptor downloads the response body to the file at filepath.
+//
+// The body is written to filepath + ".part" first, which is renamed to filepath once
+// the download is complete and verified, so filepath never holds a partial file.
+// Without WithResume, the partial file is removed when the download fails.
+func Interceptor(filepath string, opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
 		err = createDirectory(filepathutils.Dir(filepath))
 		if err != nil {
 			return nil, err
 		}
-		if _, err = os.Stat(filepath); err != nil {
-			if os.IsExist(err) {
-				return
-			}
+		d := &download{
+			cli:      cli,
+			req:      req,
+			do:       do,
+			o:        o,
+			path:     filepath,
+			partPath: filepath + kPartSuffix,
+			metaPath: filepath + kMetaSuffix,
+		}
+		reply, err = d.run()
+		if err != nil && (!o.resume || d.discard) {
+			_ = os.Remove(d.partPath)
+			_ = os.Remove(d.metaPath)
 		}
+		return reply, err
+	}
+}
 
-		reply, err = do(cli, req)
-		if err != nil {
-			return reply, err
+type download struct {
+	cli      *easyhttp.Client
+	req      *easyhttp.Request
+	do       easyhttp.Doer
+	o        *options
+	path     string
+	partPath string
+	metaPath string
+	// discard reports whether the partial file can not be resumed
+	discard bool
+}
+
+func (d *download) run() (reply *easyhttp.Reply, err error) {
+	offset, validator := d.resumePoint()
+	rawRequest := d.req.RawRequest()
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	if d.o.resume || d.o.concurrency > 1 {
+		// byte ranges must address the file, not an encoded representation of it
+		rawRequest.Header.Set(kAcceptEncodingKey, "identity")
+	}
+	if offset > 0 {
+		rawRequest.Header.Set(kRangeKey, fmt.Sprintf("bytes=%d-", offset))
+		rawRequest.Header.Set(kIfRangeKey, validator)
+	}
+
+	reply, err = d.do(d.cli, d.req)
+	if err != nil {
+		return reply, err
+	}
+	if reply == nil || reply.RawResponse() == nil {
+		return reply, err
+	}
+	rawResponse := reply.RawResponse()
+	if rawResponse.Body != nil {
+		defer ioutils.CloseThrowError(rawResponse.Body, &err)
+	}
+
+	switch rawResponse.StatusCode {
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+		start, _, _, e := parseContentRange(rawResponse.Header.Get(kContentRangeKey))
+		if e != nil || start != offset {
+			d.discard = true
+			return reply, fmt.Errorf("easyhttpdownload: unexpected Content-Range %q", rawResponse.Header.Get(kContentRangeKey))
 		}
-		rawResponse := reply.RawResponse()
-		if reply == nil || rawResponse == nil {
-			return reply, err
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file may already be complete
+		_, _, total, e := parseContentRange(rawResponse.Header.Get(kContentRangeKey))
+		if offset == 0 || e != nil || total != offset {
+			d.discard = true
+			return reply, easyhttp.NewStatusError(reply, easyhttp.DefaultMaxErrorBodySize)
 		}
-		if rawResponse.ContentLength == 0 {
+		return reply, d.complete(rawResponse)
+	default:
+		return reply, easyhttp.NewStatusError(reply, easyhttp.DefaultMaxErrorBodySize)
+	}
+
+	if offset == 0 {
+		if err = d.saveValidator(rawResponse); err != nil {
 			return reply, err
 		}
-		if rawResponse.Body != nil {
-			defer ioutils.CloseThrowError(rawResponse.Body, &err)
-		}
+	}
+	fd, err := os.OpenFile(d.partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return reply, err
+	}
+	if err = d.write(fd, rawResponse, offset); err != nil {
+		_ = fd.Close()
+		return reply, err
+	}
+	if err = fd.Close(); err != nil {
+		return reply, err
+	}
+	return reply, d.complete(rawResponse)
+}
 
-		fd, err := os.Create(filepath)
-		if err != nil {
-			return reply, err
+// write writes the response body to the partial file fd at offset.
+func (d *download) write(fd *os.File, rawResponse *http.Response, offset int64) error {
+	if offset == 0 {
+		if err := fd.Truncate(0); err != nil {
+			return err
 		}
-		defer ioutils.CloseThrowError(fd, &err)
-
-		var body io.Reader
-		body = rawResponse.Body
-
-		cek := rawResponse.Header.Get(kContentEncodingKey)
-		if strings.EqualFold(cek, "gzip") {
-			if _, ok := rawResponse.Body.(*gzip.Reader); !ok {
-				gzipReader, err := gzip.NewReader(rawResponse.Body)
-				if err != nil {
-					return reply, err
-				}
-				defer ioutils.CloseThrowError(gzipReader, &err)
-				body = gzipReader
+	}
+	if d.concurrent(rawResponse) {
+		d.discard = true
+		return d.parallel(fd, rawResponse)
+	}
+	if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	var body io.Reader = rawResponse.Body
+	cek := rawResponse.Header.Get(kContentEncodingKey)
+	if strings.EqualFold(cek, "gzip") {
+		if _, ok := rawResponse.Body.(*gzip.Reader); !ok {
+			gzipReader, err := gzip.NewReader(rawResponse.Body)
+			if err != nil {
+				return err
 			}
+			defer ioutils.CloseQuietly(gzipReader)
+			body = gzipReader
 		}
+	}
+	if _, err := io.Copy(fd, body); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
 
-		if _, err := io.Copy(fd, body); err != nil && err != io.EOF {
-			return reply, err
+// complete verifies the partial file and moves it to the final path.
+func (d *download) complete(rawResponse *http.Response) error {
+	checksums := d.o.checksums
+	if d.o.verifyDigest {
+		checksums = append(checksums[:len(checksums):len(checksums)], headerChecksums(rawResponse)...)
+	}
+	if err := verify(d.partPath, checksums); err != nil {
+		d.discard = true
+		return err
+	}
+	if err := os.Rename(d.partPath, d.path); err != nil {
+		return err
+	}
+	_ = os.Remove(d.metaPath)
+	return nil
+}
+
+// resumePoint returns the size of the partial file and the validator of its remote file.
+func (d *download) resumePoint() (int64, string) {
+	if !d.o.resume {
+		return 0, ""
+	}
+	info, err := os.Stat(d.partPath)
+	if err != nil || info.Size() == 0 {
+		return 0, ""
+	}
+	validator, err := ioutil.ReadFile(d.metaPath)
+	if err != nil || len(validator) == 0 {
+		return 0, ""
+	}
+	return info.Size(), string(validator)
+}
+
+// saveValidator saves the validator used by If-Range to resume the download.
+func (d *download) saveValidator(rawResponse *http.Response) error {
+	if !d.o.resume {
+		return nil
+	}
+	validator := validatorOf(rawResponse)
+	if validator == "" {
+		_ = os.Remove(d.metaPath)
+		return nil
+	}
+	return ioutil.WriteFile(d.metaPath, []byte(validator), 0644)
+}
+
+// validatorOf returns the validator of the response used by If-Range.
+// A weak ETag can not be used by If-Range, Last-Modified is used instead.
+func validatorOf(rawResponse *http.Response) string {
+	validator := rawResponse.Header.Get(kETagKey)
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = rawResponse.Header.Get(kLastModifiedKey)
+	}
+	return validator
+}
+
+// parseContentRange parses "bytes start-end/total" and "bytes */total", an unknown total is -1.
+func parseContentRange(contentRange string) (start, end, total int64, err error) {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, 0, 0, errors.New("invalid Content-Range")
+	}
+	spec := strings.TrimPrefix(contentRange, "bytes ")
+	i := strings.Index(spec, "/")
+	if i < 0 {
+		return 0, 0, 0, errors.New("invalid Content-Range")
+	}
+	total = -1
+	if spec[i+1:] != "*" {
+		if _, err = fmt.Sscanf(spec[i+1:], "%d", &total); err != nil {
+			return 0, 0, 0, err
 		}
-		return reply, err
 	}
+	if spec[:i] == "*" {
+		return -1, -1, total, nil
+	}
+	if _, err = fmt.Sscanf(spec[:i], "%d-%d", &start, &end); err != nil {
+		return 0, 0, 0, err
+	}
+	return start, end, total, nil
 }
 
 func createDirectory(dir string) (err error) {
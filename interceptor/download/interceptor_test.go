@@ -0,0 +1,117 @@
+package easyhttpdownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+var content = []byte(strings.Repeat("0123456789", 1000))
+
+func newServer(ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "easyhttp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func assertFile(t *testing.T, path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Fatalf("unexpected content of %d bytes", len(data))
+	}
+	if _, err := os.Stat(path + kPartSuffix); !os.IsNotExist(err) {
+		t.Fatalf("partial file is left, %v", err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.bin")
+	if err := ioutil.WriteFile(path+kPartSuffix, content[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+kMetaSuffix, []byte(`"v1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var ranges []string
+	server := newServer(&ranges)
+	defer server.Close()
+
+	sum := sha256.Sum256(content)
+	_, err := easyhttp.NewClient().Get(context.Background(), server.URL,
+		Interceptor(path, WithResume(), WithSHA256(hex.EncodeToString(sum[:]))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path)
+	if len(ranges) != 1 || ranges[0] != "bytes=4000-" {
+		t.Fatalf("unexpected ranges %q", ranges)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.bin")
+	var ranges []string
+	server := newServer(&ranges)
+	defer server.Close()
+
+	_, err := easyhttp.NewClient().Get(context.Background(), server.URL, Interceptor(path, WithConcurrency(4, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path)
+	if len(ranges) != 4 {
+		t.Fatalf("unexpected ranges %q", ranges)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.bin")
+	var ranges []string
+	server := newServer(&ranges)
+	defer server.Close()
+
+	_, err := easyhttp.NewClient().Get(context.Background(), server.URL,
+		Interceptor(path, WithResume(), WithSHA256(strings.Repeat("00", sha256.Size))))
+	if err == nil {
+		t.Fatal("expected checksum error")
+	}
+	for _, p := range []string{path, path + kPartSuffix, path + kMetaSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("%s is left, %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package easyhttpdownload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+type options struct {
+	resume       bool
+	concurrency  int
+	minChunkSize int64
+	checksums    []checksum
+	verifyDigest bool
+}
+
+type checksum struct {
+	name     string
+	newHash  func() hash.Hash
+	expected []byte
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+const (
+	defaultMinChunkSize = 1 << 20
+	defaultConcurrency  = 1
+)
+
+func defaultOptions() *options {
+	return &options{
+		concurrency:  defaultConcurrency,
+		minChunkSize: defaultMinChunkSize,
+	}
+}
+
+type Option func(o *options)
+
+// WithResume keeps the partial file of an interrupted download, and resumes it next time
+// with a Range request. If-Range with the ETag (or Last-Modified) of the first response
+// makes sure the remote file has not changed, otherwise the download starts over.
+func WithResume() Option {
+	return func(o *options) {
+		o.resume = true
+	}
+}
+
+// WithConcurrency splits the download into n concurrent range requests,
+// when the server advertises "Accept-Ranges: bytes" and every range would be at least minChunkSize bytes.
+// A partial file of a concurrent download can not be resumed.
+func WithConcurrency(n int, minChunkSize int64) Option {
+	return func(o *options) {
+		o.concurrency = n
+		o.minChunkSize = minChunkSize
+	}
+}
+
+// WithChecksum verifies the downloaded file against expectedHex, the hex encoded digest computed by newHash.
+func WithChecksum(newHash func() hash.Hash, expectedHex string) Option {
+	return func(o *options) {
+		expected, err := hex.DecodeString(expectedHex)
+		if err != nil {
+			panic(err)
+		}
+		o.checksums = append(o.checksums, checksum{name: "checksum", newHash: newHash, expected: expected})
+	}
+}
+
+// WithSHA256 verifies the SHA-256 digest of the downloaded file against expectedHex.
+func WithSHA256(expectedHex string) Option {
+	return WithChecksum(sha256.New, expectedHex)
+}
+
+// WithVerifyDigest verifies the downloaded file against the Digest (SHA-256 or MD5)
+// and Content-MD5 headers of the response, if present.
+func WithVerifyDigest() Option {
+	return func(o *options) {
+		o.verifyDigest = true
+	}
+}
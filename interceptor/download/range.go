@@ -0,0 +1,105 @@
+package easyhttpdownload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/soyacen/goutils/ioutils"
+)
+
+// concurrent reports whether the complete response should be downloaded by concurrent range requests.
+func (d *download) concurrent(rawResponse *http.Response) bool {
+	return d.o.concurrency > 1 &&
+		rawResponse.StatusCode == http.StatusOK &&
+		strings.EqualFold(rawResponse.Header.Get(kAcceptRangesKey), "bytes") &&
+		rawResponse.Header.Get(kContentEncodingKey) == "" &&
+		rawResponse.ContentLength >= int64(d.o.concurrency)*d.o.minChunkSize
+}
+
+// parallel writes the first range from the body of rawResponse,
+// and fetches the other ranges by concurrent range requests.
+func (d *download) parallel(fd *os.File, rawResponse *http.Response) error {
+	total := rawResponse.ContentLength
+	if err := fd.Truncate(total); err != nil {
+		return err
+	}
+	validator := validatorOf(rawResponse)
+	ctx, cancel := context.WithCancel(d.req.Context())
+	defer cancel()
+
+	n := int64(d.o.concurrency)
+	chunkSize := total / n
+	errC := make(chan error, n)
+	go func() {
+		errC <- copyRange(fd, rawResponse.Body, 0, chunkSize)
+	}()
+	for i := int64(1); i < n; i++ {
+		start, end := i*chunkSize, (i+1)*chunkSize-1
+		if i == n-1 {
+			end = total - 1
+		}
+		go func(start, end int64) {
+			errC <- d.fetchRange(ctx, fd, start, end, validator)
+		}(start, end)
+	}
+	var err error
+	for i := int64(0); i < n; i++ {
+		if e := <-errC; e != nil && err == nil {
+			err = e
+			cancel()
+		}
+	}
+	return err
+}
+
+func (d *download) fetchRange(ctx context.Context, fd *os.File, start, end int64, validator string) (err error) {
+	req := d.req.Clone(ctx)
+	rawRequest := req.RawRequest()
+	rawRequest.Header.Set(kRangeKey, fmt.Sprintf("bytes=%d-%d", start, end))
+	if validator != "" {
+		rawRequest.Header.Set(kIfRangeKey, validator)
+	}
+	reply, err := d.do(d.cli, req)
+	if err != nil {
+		return err
+	}
+	if reply == nil || reply.RawResponse() == nil {
+		return fmt.Errorf("easyhttpdownload: no response for range %d-%d", start, end)
+	}
+	rawResponse := reply.RawResponse()
+	defer ioutils.CloseThrowError(rawResponse.Body, &err)
+	if rawResponse.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("easyhttpdownload: unexpected status %s for range %d-%d", rawResponse.Status, start, end)
+	}
+	if s, e, _, err := parseContentRange(rawResponse.Header.Get(kContentRangeKey)); err != nil || s != start || e != end {
+		return fmt.Errorf("easyhttpdownload: unexpected Content-Range %q for range %d-%d", rawResponse.Header.Get(kContentRangeKey), start, end)
+	}
+	return copyRange(fd, rawResponse.Body, start, end-start+1)
+}
+
+// copyRange copies exactly size bytes from body to fd at offset.
+func copyRange(fd *os.File, body io.Reader, offset, size int64) error {
+	written, err := io.Copy(&offsetWriter{fd: fd, offset: offset}, io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+type offsetWriter struct {
+	fd     *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.fd.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
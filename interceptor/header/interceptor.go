@@ -1,30 +1,21 @@
 package easyhttpheader
 
 import (
-	"net/http"
-
 	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/headerutils"
 )
 
 func Set(key, value string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		rawRequest := req.RawRequest()
-		if rawRequest.Header == nil {
-			rawRequest.Header = make(http.Header)
-		}
-		rawRequest.Header.Set(key, value)
+		headerutils.Set(req.RawRequest(), key, value)
 		return do(cli, req)
 	}
 }
 
 func SetMap(headers map[string]string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		rawRequest := req.RawRequest()
-		if rawRequest.Header == nil {
-			rawRequest.Header = make(http.Header)
-		}
 		for key, value := range headers {
-			rawRequest.Header.Set(key, value)
+			headerutils.Set(req.RawRequest(), key, value)
 		}
 		return do(cli, req)
 	}
@@ -32,13 +23,7 @@ func SetMap(headers map[string]string) easyhttp.Interceptor {
 
 func Add(key string, values ...string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		rawRequest := req.RawRequest()
-		if rawRequest.Header == nil {
-			rawRequest.Header = make(http.Header)
-		}
-		for _, value := range values {
-			rawRequest.Header.Add(key, value)
-		}
+		headerutils.Add(req.RawRequest(), key, values...)
 		return do(cli, req)
 	}
 }
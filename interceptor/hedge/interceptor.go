@@ -0,0 +1,220 @@
+package easyhttphedge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/bodyutils"
+)
+
+// kMaxDrainSize is the maximum size read from a losing response body, so its connection can be reused.
+const kMaxDrainSize = 64 << 10
+
+var errBodyClosed = errors.New("easyhttphedge: read on the closed body of a losing request")
+
+// Interceptor sends hedged requests: if no reply arrives after a delay, a duplicate of the request is sent,
+// the first reply wins and the other requests are canceled.
+// The request body is buffered if it can not be rewound by GetBody.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	var observed *latencies
+	if o.percentile > 0 && o.window > 0 {
+		observed = newLatencies(o.window)
+	}
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		rawRequest := req.RawRequest()
+		if o.maxHedges <= 0 || !o.policy(rawRequest) {
+			return do(cli, req)
+		}
+		if err := bodyutils.MakeRewindable(rawRequest); err != nil {
+			return nil, err
+		}
+		host := rawRequest.URL.Host
+		delay := o.delay
+		if observed != nil {
+			if d, ok := observed.percentile(host, o.percentile, o.minSamples); ok {
+				delay = d
+			}
+		}
+		h := &hedger{
+			cli:     cli,
+			req:     req,
+			do:      do,
+			results: make(chan *result, o.maxHedges+1),
+		}
+		res := h.run(delay, o.maxHedges)
+		if observed != nil && res.err == nil {
+			observed.observe(host, res.latency)
+		}
+		return res.reply, res.err
+	}
+}
+
+type hedger struct {
+	cli     *easyhttp.Client
+	req     *easyhttp.Request
+	do      easyhttp.Doer
+	results chan *result
+	cancels []context.CancelFunc
+	bodies  []*guardedBody
+}
+
+type result struct {
+	index   int
+	reply   *easyhttp.Reply
+	err     error
+	latency time.Duration
+}
+
+// run sends the request and the hedged requests, and returns the first successful reply,
+// or the last failure if every request failed.
+func (h *hedger) run(delay time.Duration, maxHedges int) *result {
+	parentCtx := h.req.Context()
+	start := time.Now()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	h.send(parentCtx)
+	pending := 1
+	var last *result
+	for {
+		select {
+		case <-timer.C:
+			if len(h.cancels) <= maxHedges && parentCtx.Err() == nil {
+				h.send(parentCtx)
+				pending++
+				timer.Reset(delay)
+			}
+		case res := <-h.results:
+			pending--
+			// the latency seen by the caller, from the original request
+			res.latency = time.Since(start)
+			if res.err == nil {
+				if last != nil {
+					h.discard(last)
+				}
+				h.finish(res, pending)
+				return res
+			}
+			if last != nil {
+				h.discard(last)
+			}
+			last = res
+			if pending > 0 {
+				continue
+			}
+			// hedges are only sent on the delay, failures are not retried
+			h.finish(last, 0)
+			return last
+		}
+	}
+}
+
+func (h *hedger) send(parentCtx context.Context) {
+	index := len(h.cancels)
+	ctx, cancel := context.WithCancel(parentCtx)
+	h.cancels = append(h.cancels, cancel)
+	req := h.req.Clone(ctx)
+	rawRequest := req.RawRequest()
+	if index > 0 && rawRequest.GetBody != nil {
+		body, err := rawRequest.GetBody()
+		if err != nil {
+			h.bodies = append(h.bodies, nil)
+			h.results <- &result{index: index, err: err}
+			return
+		}
+		rawRequest.Body = body
+	}
+	var body *guardedBody
+	if rawRequest.Body != nil && rawRequest.Body != http.NoBody {
+		body = &guardedBody{body: rawRequest.Body}
+		rawRequest.Body = body
+	}
+	h.bodies = append(h.bodies, body)
+	go func() {
+		reply, err := h.do(h.cli, req)
+		h.results <- &result{index: index, reply: reply, err: err}
+	}()
+}
+
+// finish cancels the losing requests, waits for them and discards their replies.
+// The context of the winner is canceled when its response body is closed.
+func (h *hedger) finish(winner *result, pending int) {
+	for i, cancel := range h.cancels {
+		if i != winner.index {
+			cancel()
+		}
+	}
+	cancel := h.cancels[winner.index]
+	if winner.reply != nil && winner.reply.RawResponse() != nil && winner.reply.RawResponse().Body != nil {
+		response := winner.reply.RawResponse()
+		response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+	} else {
+		cancel()
+	}
+	// the body of the request may be a buffer released by an outer interceptor once the call returns,
+	// the losing requests must not read it anymore
+	for i := 0; i < pending; i++ {
+		h.discard(<-h.results)
+	}
+	for i, body := range h.bodies {
+		if i != winner.index && body != nil {
+			body.Close()
+		}
+	}
+}
+
+// discard drains and closes the response body of a losing reply.
+func (h *hedger) discard(res *result) {
+	if res.reply == nil || res.reply.RawResponse() == nil || res.reply.RawResponse().Body == nil {
+		return
+	}
+	body := res.reply.RawResponse().Body
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, kMaxDrainSize))
+	_ = body.Close()
+}
+
+// cancelBody cancels the context of the request once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// guardedBody is the request body of an attempt, it is not read anymore once closed.
+type guardedBody struct {
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed bool
+}
+
+func (b *guardedBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, errBodyClosed
+	}
+	return b.body.Read(p)
+}
+
+func (b *guardedBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	return b.body.Close()
+}
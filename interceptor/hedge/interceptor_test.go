@@ -0,0 +1,128 @@
+package easyhttphedge
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpreqbody "github.com/soyacen/easyhttp/interceptor/reqbody"
+)
+
+func TestHedge(t *testing.T) {
+	var calls int32
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	start := time.Now()
+	reply, err := client.Put(context.Background(), server.URL,
+		easyhttpreqbody.Text("hedged"),
+		Interceptor(WithDelay(20*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := reply.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "hedged" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("hedged request took %s", elapsed)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request was not canceled")
+	}
+}
+
+func TestHedgePolicy(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	_, err := client.Post(context.Background(), server.URL, Interceptor(WithDelay(time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected POST not to be hedged, got %d calls", calls)
+	}
+}
+
+func TestHedgeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls int32
+	failing := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		return nil, errors.New("failed")
+	}
+
+	client := easyhttp.NewClient()
+	_, err := client.Get(ctx, "http://localhost", Interceptor(WithDelay(time.Second), WithMaxHedges(3)), failing)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected no hedge after cancel, got %d calls", calls)
+	}
+}
+
+func TestHedgeNoRetry(t *testing.T) {
+	var calls int32
+	failing := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("connection refused")
+	}
+
+	client := easyhttp.NewClient()
+	start := time.Now()
+	_, err := client.Get(context.Background(), "http://localhost", Interceptor(WithDelay(time.Second), WithMaxHedges(3)), failing)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected the failure to be returned at once, got %d calls", calls)
+	}
+}
+
+func TestLatencies(t *testing.T) {
+	l := newLatencies(10)
+	if _, ok := l.percentile("a", 0.9, 1); ok {
+		t.Fatal("expected no percentile without samples")
+	}
+	for i := 1; i <= 20; i++ {
+		l.observe("a", time.Duration(i)*time.Millisecond)
+	}
+	p, ok := l.percentile("a", 0.9, 5)
+	if !ok || p != 19*time.Millisecond {
+		t.Fatalf("unexpected percentile %s", p)
+	}
+}
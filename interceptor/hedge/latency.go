@@ -0,0 +1,61 @@
+package easyhttphedge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencies keeps the last latencies observed per host.
+type latencies struct {
+	window int
+
+	mu    sync.Mutex
+	hosts map[string]*samples
+}
+
+type samples struct {
+	values []time.Duration
+	next   int
+}
+
+func newLatencies(window int) *latencies {
+	return &latencies{window: window, hosts: make(map[string]*samples)}
+}
+
+func (l *latencies) observe(host string, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &samples{values: make([]time.Duration, 0, l.window)}
+		l.hosts[host] = s
+	}
+	if len(s.values) < l.window {
+		s.values = append(s.values, latency)
+		return
+	}
+	s.values[s.next] = latency
+	s.next = (s.next + 1) % l.window
+}
+
+// percentile returns the percentile of the latencies of host, false if there are less than minSamples.
+func (l *latencies) percentile(host string, percentile float64, minSamples int) (time.Duration, bool) {
+	l.mu.Lock()
+	s, ok := l.hosts[host]
+	if !ok || len(s.values) == 0 || len(s.values) < minSamples {
+		l.mu.Unlock()
+		return 0, false
+	}
+	values := append([]time.Duration(nil), s.values...)
+	l.mu.Unlock()
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	index := int(percentile*float64(len(values))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(values) {
+		index = len(values) - 1
+	}
+	return values[index], true
+}
@@ -0,0 +1,71 @@
+package easyhttphedge
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+type options struct {
+	delay      time.Duration
+	percentile float64
+	window     int
+	minSamples int
+	maxHedges  int
+	policy     func(rawRequest *http.Request) bool
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		delay:     100 * time.Millisecond,
+		maxHedges: 1,
+		policy:    IsIdempotent,
+	}
+}
+
+type Option func(o *options)
+
+// WithDelay sets the static delay before sending a hedged request. Default is 100ms.
+// With WithPercentileDelay, it is the delay used until enough latencies are observed.
+func WithDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.delay = delay
+	}
+}
+
+// WithPercentileDelay sets the delay to the percentile (0-1, e.g. 0.95) of the latencies observed per host,
+// over the last window calls. The static delay is used until minSamples latencies are observed.
+func WithPercentileDelay(percentile float64, window int, minSamples int) Option {
+	return func(o *options) {
+		o.percentile = percentile
+		o.window = window
+		o.minSamples = minSamples
+	}
+}
+
+// WithMaxHedges sets the maximum number of hedged requests sent in addition to the original one. Default is 1.
+func WithMaxHedges(n int) Option {
+	return func(o *options) {
+		o.maxHedges = n
+	}
+}
+
+// WithPolicy sets the policy deciding whether a request can be hedged, default is IsIdempotent.
+func WithPolicy(policy func(rawRequest *http.Request) bool) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// IsIdempotent reports whether the request can be sent several times without side effects,
+// see easyhttp.IsIdempotent.
+func IsIdempotent(rawRequest *http.Request) bool {
+	return easyhttp.IsIdempotent(rawRequest)
+}
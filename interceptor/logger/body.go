@@ -0,0 +1,104 @@
+package easyhttplogger
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// captureReader captures the first max bytes read from the body.
+type captureReader struct {
+	io.ReadCloser
+	max int64
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.mu.Lock()
+		if remaining := r.max - int64(r.buf.Len()); remaining > 0 {
+			if int64(n) < remaining {
+				remaining = int64(n)
+			}
+			r.buf.Write(p[:remaining])
+		}
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+func (r *captureReader) captured() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+// captureRequestBody tees the request body while the transport sends it.
+func captureRequestBody(rawRequest *http.Request, max int64) *captureReader {
+	if rawRequest.Body == nil || rawRequest.Body == http.NoBody {
+		return nil
+	}
+	capture := &captureReader{ReadCloser: rawRequest.Body, max: max}
+	rawRequest.Body = capture
+	return capture
+}
+
+// replayRequestBody reads the first max bytes of the body given by GetBody, nil if it can not be replayed.
+func replayRequestBody(rawRequest *http.Request, max int64) []byte {
+	if rawRequest.GetBody == nil {
+		return nil
+	}
+	body, err := rawRequest.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	data, _ := ioutil.ReadAll(io.LimitReader(body, max))
+	return data
+}
+
+// captureResponseBody tees the response body while the caller reads it, and calls done with the
+// captured bytes once max bytes are read, the body is read to its end or closed.
+// It returns false if the response has no body.
+func captureResponseBody(response *http.Response, max int64, done func(body []byte)) bool {
+	if response.Body == nil || response.Body == http.NoBody {
+		return false
+	}
+	response.Body = &responseCapture{captureReader: &captureReader{ReadCloser: response.Body, max: max}, done: done}
+	return true
+}
+
+type responseCapture struct {
+	*captureReader
+	once sync.Once
+	done func(body []byte)
+}
+
+func (r *responseCapture) Read(p []byte) (int, error) {
+	n, err := r.captureReader.Read(p)
+	if err != nil || r.full() {
+		r.finish()
+	}
+	return n, err
+}
+
+func (r *responseCapture) Close() error {
+	err := r.captureReader.Close()
+	r.finish()
+	return err
+}
+
+func (r *responseCapture) finish() {
+	r.once.Do(func() { r.done(r.captured()) })
+}
+
+func (r *captureReader) full() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(r.buf.Len()) >= r.max
+}
@@ -17,7 +17,7 @@ func NewFieldBuilder() *FieldBuilder {
 }
 
 func (f *FieldBuilder) System() *FieldBuilder {
-	f.fields["system"] = "http.server"
+	f.fields["system"] = "http.client"
 	return f
 }
 
@@ -48,6 +48,11 @@ func (f *FieldBuilder) URI(uri string) *FieldBuilder {
 	return f
 }
 
+func (f *FieldBuilder) Route(route string) *FieldBuilder {
+	f.fields["http.route"] = route
+	return f
+}
+
 func (f *FieldBuilder) RequestHeader(header http.Header) *FieldBuilder {
 	f.fields["http.request.header"] = header
 	return f
@@ -68,6 +73,26 @@ func (f *FieldBuilder) StatusCode(statusCode int) *FieldBuilder {
 	return f
 }
 
+func (f *FieldBuilder) RequestBody(body string) *FieldBuilder {
+	f.fields["http.request.body"] = body
+	return f
+}
+
+func (f *FieldBuilder) ResponseBody(body string) *FieldBuilder {
+	f.fields["http.response.body"] = body
+	return f
+}
+
+func (f *FieldBuilder) Attempt(attempt uint) *FieldBuilder {
+	f.fields["http.attempt"] = attempt
+	return f
+}
+
+func (f *FieldBuilder) Level(level Level) *FieldBuilder {
+	f.fields["level"] = level.String()
+	return f
+}
+
 func (f *FieldBuilder) Error(err error) *FieldBuilder {
 	if err == nil {
 		return f
@@ -1,11 +1,15 @@
 package easyhttplogger
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/soyacen/easyhttp"
 )
 
+const kLogMessage = "http client request"
+
 func Interceptor(opts ...Option) easyhttp.Interceptor {
 	o := defaultOptions()
 	o.apply(opts...)
@@ -14,23 +18,80 @@ func Interceptor(opts ...Option) easyhttp.Interceptor {
 			return do(cli, req)
 		}
 		logger := o.loggerFactory(req.RawRequest().Context())
+		var requestBody *captureReader
+		if o.requestBody > 0 {
+			requestBody = captureRequestBody(req.RawRequest(), o.requestBody)
+		}
 		startTime := time.Now()
-		r, err := do(cli, req)
+		reply, err = do(cli, req)
+		latency := time.Since(startTime)
+
+		level := o.levelFunc(reply, err)
+		if o.sampler != nil && !o.sampler(level) {
+			return reply, err
+		}
 		rawRequest := req.RawRequest()
-		rawResponse := r.RawResponse()
 		builder := NewFieldBuilder().
 			System().
 			StartTime(startTime).
-			Deadline(req.RawRequest().Context()).
+			Deadline(rawRequest.Context()).
 			Method(rawRequest.Method).
-			URI(rawRequest.URL.String()).
-			RequestHeader(rawRequest.Header.Clone()).
-			Latency(time.Since(startTime)).
-			Status(rawResponse.Status).
-			StatusCode(rawResponse.StatusCode).
-			ResponseHeader(rawResponse.Header.Clone()).
+			URI(redactURL(rawRequest.URL, o.redactQuery)).
+			RequestHeader(redactHeader(rawRequest.Header, o.redactHeaders)).
+			Latency(latency).
 			Error(err)
-		logger.Log(builder.Build())
-		return r, err
+		if route, ok := easyhttp.RouteFromContext(rawRequest.Context()); ok {
+			builder.Route(route)
+		}
+		if attempt, ok := easyhttp.AttemptFromContext(rawRequest.Context()); ok {
+			builder.Attempt(attempt)
+		}
+		if requestBody != nil {
+			builder.RequestBody(o.body(rawRequest.Header, requestBody.captured()))
+		} else if o.requestBody > 0 {
+			// the body was set by an inner interceptor, it is replayed when possible
+			if body := replayRequestBody(rawRequest, o.requestBody); body != nil {
+				builder.RequestBody(o.body(rawRequest.Header, body))
+			}
+		}
+		log := func() {
+			if levelLogger, ok := logger.(LevelLogger); ok {
+				levelLogger.LogLevel(level, kLogMessage, builder.Build())
+			} else {
+				logger.Log(builder.Level(level).Build())
+			}
+		}
+		if reply != nil && reply.RawResponse() != nil {
+			rawResponse := reply.RawResponse()
+			builder.Status(rawResponse.Status).
+				StatusCode(rawResponse.StatusCode).
+				ResponseHeader(redactHeader(rawResponse.Header, o.redactHeaders))
+			var statusErr *easyhttp.StatusError
+			if o.responseBody > 0 && errors.As(err, &statusErr) {
+				// the caller may never read the body of an error, it is already peeked in the error
+				body := statusErr.Body
+				if int64(len(body)) > o.responseBody {
+					body = body[:o.responseBody]
+				}
+				builder.ResponseBody(o.body(rawResponse.Header, body))
+			} else if o.responseBody > 0 {
+				// the body may be a stream, it is captured while the caller reads it
+				if captureResponseBody(rawResponse, o.responseBody, func(body []byte) {
+					builder.ResponseBody(o.body(rawResponse.Header, body))
+					log()
+				}) {
+					return reply, err
+				}
+			}
+		}
+		log()
+		return reply, err
+	}
+}
+
+func (o *options) body(header http.Header, body []byte) string {
+	if o.redactBody != nil {
+		body = o.redactBody(header.Get("Content-Type"), body)
 	}
+	return string(body)
 }
@@ -0,0 +1,222 @@
+package easyhttplogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpreqbody "github.com/soyacen/easyhttp/interceptor/reqbody"
+)
+
+type recordLogger struct {
+	mu     sync.Mutex
+	levels []Level
+	fields []map[string]interface{}
+}
+
+func (l *recordLogger) Log(fields map[string]interface{}) {
+	l.LogLevel(LevelInfo, "", fields)
+}
+
+func (l *recordLogger) LogLevel(level Level, msg string, fields map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels = append(l.levels, level)
+	l.fields = append(l.fields, fields)
+}
+
+func (l *recordLogger) factory(ctx context.Context) Logger {
+	return l
+}
+
+func TestInterceptorError(t *testing.T) {
+	logger := &recordLogger{}
+	client := easyhttp.NewClient()
+	_, err := client.Get(context.Background(), "http://127.0.0.1:1",
+		Interceptor(WithLoggerFactory(logger.factory)))
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if len(logger.fields) != 1 || logger.levels[0] != LevelError {
+		t.Fatalf("expected an error log, got %v", logger.levels)
+	}
+	if logger.fields[0]["system"] != "http.client" || logger.fields[0]["error"] == nil {
+		t.Fatalf("unexpected fields %v", logger.fields[0])
+	}
+	if _, ok := logger.fields[0]["http.response.statusCode"]; ok {
+		t.Fatal("unexpected response fields without reply")
+	}
+}
+
+func TestInterceptorRedactAndBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	logger := &recordLogger{}
+	client := easyhttp.NewClient()
+	payload := `{"user":"alice","password":"secret","padding":"` + strings.Repeat("x", 100) + `"}`
+	reply, err := client.Post(context.Background(), server.URL+"/users?token=secret&page=1",
+		easyhttpreqbody.Text(payload),
+		func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+			req.RawRequest().Header.Set("Authorization", "Bearer secret")
+			return do(cli, req)
+		},
+		Interceptor(
+			WithLoggerFactory(logger.factory),
+			WithRequestBody(16),
+			WithResponseBody(1024),
+			WithRedactBody(RedactJSONFields("password")),
+		))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := reply.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != payload {
+		t.Fatalf("response body is consumed, got %q", body)
+	}
+
+	fields := logger.fields[0]
+	if logger.levels[0] != LevelWarn {
+		t.Fatalf("expected warn level for 404, got %v", logger.levels[0])
+	}
+	if uri := fields["http.uri"].(string); strings.Contains(uri, "secret") || !strings.Contains(uri, "page=1") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	if header := fields["http.request.header"].(http.Header); header.Get("Authorization") != Redacted {
+		t.Fatalf("authorization is not redacted: %v", header)
+	}
+	if header := fields["http.response.header"].(http.Header); header.Get("Set-Cookie") != Redacted {
+		t.Fatalf("set-cookie is not redacted: %v", header)
+	}
+	if requestBody := fields["http.request.body"].(string); requestBody != payload[:16] {
+		t.Fatalf("unexpected request body %q", requestBody)
+	}
+	responseBody := fields["http.response.body"].(string)
+	if strings.Contains(responseBody, "secret") || !strings.Contains(responseBody, "alice") {
+		t.Fatalf("unexpected response body %q", responseBody)
+	}
+}
+
+func TestClientInterceptorRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	logger := &recordLogger{}
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(
+		Interceptor(WithLoggerFactory(logger.factory), WithRequestBody(7)),
+	))
+	if _, err := client.Post(context.Background(), server.URL, easyhttpreqbody.Text("payload set per call")); err != nil {
+		t.Fatal(err)
+	}
+	if body := logger.fields[0]["http.request.body"]; body != "payload" {
+		t.Fatalf("unexpected request body %q", body)
+	}
+}
+
+func TestInterceptorStreamingResponseBody(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte(" last chunk"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	logger := &recordLogger{}
+	client := easyhttp.NewClient()
+	reply, err := client.Get(context.Background(), server.URL,
+		Interceptor(WithLoggerFactory(logger.factory), WithResponseBody(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.mu.Lock()
+	logged := len(logger.fields)
+	logger.mu.Unlock()
+	if logged != 0 {
+		t.Fatal("expected the log to wait for the body")
+	}
+	chunk := make([]byte, len("first chunk"))
+	if _, err := io.ReadFull(reply.RawResponse().Body, chunk); err != nil {
+		t.Fatal(err)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.fields) != 1 {
+		t.Fatalf("expected a log once the body is captured, got %d", len(logger.fields))
+	}
+	if body := logger.fields[0]["http.response.body"]; body != "first" {
+		t.Fatalf("unexpected response body %q", body)
+	}
+	reply.RawResponse().Body.Close()
+}
+
+func TestInterceptorSampler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	logger := &recordLogger{}
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(
+		Interceptor(WithLoggerFactory(logger.factory), WithSampler(RateSampler(0)))))
+	for _, path := range []string{"/ok", "/fail", "/ok"} {
+		if _, err := client.Get(context.Background(), server.URL+path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(logger.levels) != 1 || logger.levels[0] != LevelError {
+		t.Fatalf("expected only the error to be logged, got %v", logger.levels)
+	}
+}
+
+func TestDefaultLevel(t *testing.T) {
+	if level := DefaultLevel(nil, errors.New("failed")); level != LevelError {
+		t.Fatalf("expected error level, got %v", level)
+	}
+	if level := DefaultLevel(nil, &easyhttp.StatusError{StatusCode: http.StatusTooManyRequests}); level != LevelWarn {
+		t.Fatalf("expected warn level, got %v", level)
+	}
+}
+
+type plainLogger struct {
+	fields map[string]interface{}
+}
+
+func (l *plainLogger) Log(fields map[string]interface{}) {
+	l.fields = fields
+}
+
+func TestInterceptorPlainLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	logger := &plainLogger{}
+	_, err := easyhttp.NewClient().Get(context.Background(), server.URL, Interceptor(WithLoggerFactory(
+		func(ctx context.Context) Logger { return logger })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.fields["level"] != "info" || logger.fields["http.response.statusCode"] != http.StatusOK {
+		t.Fatalf("unexpected fields %v", logger.fields)
+	}
+}
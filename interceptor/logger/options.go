@@ -2,16 +2,100 @@ package easyhttplogger
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
 )
 
 type Logger interface {
 	Log(fields map[string]interface{})
 }
 
+// LevelLogger is a Logger aware of levels, the level is given to LogLevel instead of a level field.
+type LevelLogger interface {
+	Logger
+	LogLevel(level Level, msg string, fields map[string]interface{})
+}
+
 type LoggerFactory func(ctx context.Context) Logger
 
+// Level is the level of a log.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// LevelFunc returns the level of the log of a call.
+type LevelFunc func(reply *easyhttp.Reply, err error) Level
+
+// DefaultLevel logs errors and 5xx replies as error, 4xx replies as warn and the others as info.
+func DefaultLevel(reply *easyhttp.Reply, err error) Level {
+	statusCode := 0
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		statusCode = statusErr.StatusCode
+	} else if err != nil {
+		return LevelError
+	} else if reply != nil && reply.RawResponse() != nil {
+		statusCode = reply.RawResponse().StatusCode
+	}
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return LevelError
+	case statusCode >= http.StatusBadRequest:
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// Sampler reports whether a log of level is written.
+type Sampler func(level Level) bool
+
+// RateSampler writes a rate (0-1) of the logs below warn, warn and error logs are always written.
+func RateSampler(rate float64) Sampler {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func(level Level) bool {
+		if level >= LevelWarn {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return r.Float64() < rate
+	}
+}
+
 type options struct {
 	loggerFactory LoggerFactory
+	redactHeaders []string
+	redactQuery   []string
+	redactBody    BodyRedactor
+	requestBody   int64
+	responseBody  int64
+	levelFunc     LevelFunc
+	sampler       Sampler
 }
 
 func (o *options) apply(opts ...Option) {
@@ -23,7 +107,11 @@ func (o *options) apply(opts ...Option) {
 type Option func(o *options)
 
 func defaultOptions() *options {
-	return &options{}
+	return &options{
+		redactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		redactQuery:   []string{"access_token", "api_key", "apikey", "password", "token"},
+		levelFunc:     DefaultLevel,
+	}
 }
 
 func WithLoggerFactory(loggerFactory LoggerFactory) Option {
@@ -31,3 +119,57 @@ func WithLoggerFactory(loggerFactory LoggerFactory) Option {
 		o.loggerFactory = loggerFactory
 	}
 }
+
+// WithRedactHeaders sets the request and response headers whose values are redacted.
+// Default is Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key.
+func WithRedactHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.redactHeaders = headers
+	}
+}
+
+// WithRedactQuery sets the query params whose values are redacted in the logged uri.
+// Default is access_token, api_key, apikey, password and token.
+func WithRedactQuery(params ...string) Option {
+	return func(o *options) {
+		o.redactQuery = params
+	}
+}
+
+// WithRedactBody sets the redactor of the captured bodies, e.g. RedactJSONFields.
+func WithRedactBody(redactor BodyRedactor) Option {
+	return func(o *options) {
+		o.redactBody = redactor
+	}
+}
+
+// WithRequestBody logs the first max bytes of the request bodies.
+// The body is captured while it is sent, it is not read by the logger.
+func WithRequestBody(max int64) Option {
+	return func(o *options) {
+		o.requestBody = max
+	}
+}
+
+// WithResponseBody logs the first max bytes of the response bodies.
+// They are captured while the body is read, so streams are not blocked: the log of a reply
+// with a body is written once max bytes are read, the body is read to its end or closed.
+func WithResponseBody(max int64) Option {
+	return func(o *options) {
+		o.responseBody = max
+	}
+}
+
+// WithLevel sets the function returning the level of the log of a call, default is DefaultLevel.
+func WithLevel(levelFunc LevelFunc) Option {
+	return func(o *options) {
+		o.levelFunc = levelFunc
+	}
+}
+
+// WithSampler sets the sampler of the logs, e.g. RateSampler. By default every log is written.
+func WithSampler(sampler Sampler) Option {
+	return func(o *options) {
+		o.sampler = sampler
+	}
+}
@@ -0,0 +1,89 @@
+package easyhttplogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the redacted values.
+const Redacted = "[REDACTED]"
+
+// BodyRedactor returns the body to log, given its content type.
+type BodyRedactor func(contentType string, body []byte) []byte
+
+// RedactJSONFields redacts the values of the fields of JSON bodies, at any depth.
+// A truncated body can not be parsed, it is replaced by Redacted.
+func RedactJSONFields(fields ...string) BodyRedactor {
+	names := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		names[strings.ToLower(field)] = struct{}{}
+	}
+	return func(contentType string, body []byte) []byte {
+		if !strings.Contains(contentType, "json") || len(body) == 0 {
+			return body
+		}
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return []byte(Redacted)
+		}
+		data, err := json.Marshal(redactJSON(v, names))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		return data
+	}
+}
+
+func redactJSON(v interface{}, names map[string]struct{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if _, ok := names[strings.ToLower(key)]; ok {
+				value[key] = Redacted
+				continue
+			}
+			value[key] = redactJSON(field, names)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactJSON(item, names)
+		}
+	}
+	return v
+}
+
+func redactHeader(header http.Header, names []string) http.Header {
+	redacted := header.Clone()
+	for _, name := range names {
+		if _, ok := redacted[http.CanonicalHeaderKey(name)]; ok {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+func redactURL(u *url.URL, params []string) string {
+	if u.RawQuery == "" && u.User == nil {
+		return u.String()
+	}
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User(redacted.User.Username())
+	}
+	query := redacted.Query()
+	changed := false
+	for key := range query {
+		for _, param := range params {
+			if strings.EqualFold(key, param) {
+				query[key] = []string{Redacted}
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
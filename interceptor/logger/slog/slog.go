@@ -0,0 +1,64 @@
+//go:build go1.21
+// +build go1.21
+
+// Package loggerslog adapts a log/slog logger to easyhttplogger.
+package loggerslog
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+)
+
+type Logger struct {
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// New returns a Logger writing to logger.
+func New(logger *slog.Logger) *Logger {
+	return &Logger{logger: logger, ctx: context.Background()}
+}
+
+// Factory returns a LoggerFactory writing to logger with the context of the requests.
+func Factory(logger *slog.Logger) easyhttplogger.LoggerFactory {
+	return func(ctx context.Context) easyhttplogger.Logger {
+		return &Logger{logger: logger, ctx: ctx}
+	}
+}
+
+func (l *Logger) Log(fields map[string]interface{}) {
+	l.LogLevel(easyhttplogger.LevelInfo, "", fields)
+}
+
+func (l *Logger) LogLevel(level easyhttplogger.Level, msg string, fields map[string]interface{}) {
+	l.logger.LogAttrs(l.ctx, toLevel(level), msg, toAttrs(fields)...)
+}
+
+func toLevel(level easyhttplogger.Level) slog.Level {
+	switch level {
+	case easyhttplogger.LevelDebug:
+		return slog.LevelDebug
+	case easyhttplogger.LevelInfo:
+		return slog.LevelInfo
+	case easyhttplogger.LevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func toAttrs(fields map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, fields[key]))
+	}
+	return attrs
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package loggerslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+)
+
+func TestLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := Factory(slog.New(handler))(context.Background()).(easyhttplogger.LevelLogger)
+
+	logger.LogLevel(easyhttplogger.LevelDebug, "dropped", nil)
+	logger.LogLevel(easyhttplogger.LevelError, "finished call", map[string]interface{}{
+		"http.status_code": 502,
+		"http.method":      "GET",
+	})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single entry, got %q", buf.String())
+	}
+	if entry["level"] != "ERROR" || entry["msg"] != "finished call" ||
+		entry["http.status_code"] != float64(502) || entry["http.method"] != "GET" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+}
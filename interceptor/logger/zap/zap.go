@@ -0,0 +1,68 @@
+// Package loggerzap adapts a zap logger to easyhttplogger.
+package loggerzap
+
+import (
+	"context"
+	"sort"
+
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Logger struct {
+	logger *zap.Logger
+}
+
+// New returns a Logger writing to logger.
+func New(logger *zap.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Factory returns a LoggerFactory writing to logger.
+func Factory(logger *zap.Logger) easyhttplogger.LoggerFactory {
+	l := New(logger)
+	return func(ctx context.Context) easyhttplogger.Logger {
+		return l
+	}
+}
+
+func (l *Logger) Log(fields map[string]interface{}) {
+	l.LogLevel(easyhttplogger.LevelInfo, "", fields)
+}
+
+func (l *Logger) LogLevel(level easyhttplogger.Level, msg string, fields map[string]interface{}) {
+	if ce := l.logger.Check(toLevel(level), msg); ce != nil {
+		ce.Write(toFields(fields)...)
+	}
+}
+
+func toLevel(level easyhttplogger.Level) zapcore.Level {
+	switch level {
+	case easyhttplogger.LevelDebug:
+		return zapcore.DebugLevel
+	case easyhttplogger.LevelInfo:
+		return zapcore.InfoLevel
+	case easyhttplogger.LevelWarn:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func toFields(fields map[string]interface{}) []zap.Field {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	zapFields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		if err, ok := fields[key].(error); ok {
+			zapFields = append(zapFields, zap.NamedError(key, err))
+			continue
+		}
+		zapFields = append(zapFields, zap.Any(key, fields[key]))
+	}
+	return zapFields
+}
@@ -0,0 +1,41 @@
+package loggerzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+)
+
+func TestLogLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := Factory(zap.New(core))(context.Background()).(easyhttplogger.LevelLogger)
+
+	logger.LogLevel(easyhttplogger.LevelDebug, "dropped", nil)
+	logger.LogLevel(easyhttplogger.LevelWarn, "finished call", map[string]interface{}{
+		"http.status_code": 404,
+		"error":            errors.New("not found"),
+	})
+	logger.Log(map[string]interface{}{"http.method": "GET"})
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	warn := entries[0]
+	if warn.Level != zapcore.WarnLevel || warn.Message != "finished call" {
+		t.Fatalf("unexpected entry %v %q", warn.Level, warn.Message)
+	}
+	fields := warn.ContextMap()
+	if fields["http.status_code"] != int64(404) || fields["error"] != "not found" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if info := entries[1]; info.Level != zapcore.InfoLevel || info.ContextMap()["http.method"] != "GET" {
+		t.Fatalf("unexpected entry %v %v", info.Level, info.ContextMap())
+	}
+}
@@ -0,0 +1,50 @@
+// Package loggerzerolog adapts a zerolog logger to easyhttplogger.
+package loggerzerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+)
+
+type Logger struct {
+	logger zerolog.Logger
+}
+
+// New returns a Logger writing to logger.
+func New(logger zerolog.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Factory returns a LoggerFactory writing to the logger of the context of the requests,
+// or to logger when the context has none.
+func Factory(logger zerolog.Logger) easyhttplogger.LoggerFactory {
+	return func(ctx context.Context) easyhttplogger.Logger {
+		if ctxLogger := zerolog.Ctx(ctx); ctxLogger.GetLevel() != zerolog.Disabled {
+			return New(*ctxLogger)
+		}
+		return New(logger)
+	}
+}
+
+func (l *Logger) Log(fields map[string]interface{}) {
+	l.LogLevel(easyhttplogger.LevelInfo, "", fields)
+}
+
+func (l *Logger) LogLevel(level easyhttplogger.Level, msg string, fields map[string]interface{}) {
+	l.logger.WithLevel(toLevel(level)).Fields(fields).Msg(msg)
+}
+
+func toLevel(level easyhttplogger.Level) zerolog.Level {
+	switch level {
+	case easyhttplogger.LevelDebug:
+		return zerolog.DebugLevel
+	case easyhttplogger.LevelInfo:
+		return zerolog.InfoLevel
+	case easyhttplogger.LevelWarn:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
@@ -0,0 +1,46 @@
+package loggerzerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	easyhttplogger "github.com/soyacen/easyhttp/interceptor/logger"
+)
+
+func TestLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := Factory(zerolog.New(&buf).Level(zerolog.InfoLevel))(context.Background()).(easyhttplogger.LevelLogger)
+
+	logger.LogLevel(easyhttplogger.LevelDebug, "dropped", nil)
+	logger.LogLevel(easyhttplogger.LevelWarn, "finished call", map[string]interface{}{"http.status_code": 404})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single entry, got %q", buf.String())
+	}
+	if entry["level"] != "warn" || entry["message"] != "finished call" || entry["http.status_code"] != float64(404) {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	var buf, ctxBuf bytes.Buffer
+	ctxLogger := zerolog.New(&ctxBuf)
+	ctx := ctxLogger.WithContext(context.Background())
+	Factory(zerolog.New(&buf))(ctx).Log(map[string]interface{}{"http.method": "GET"})
+
+	if buf.Len() != 0 {
+		t.Fatalf("the logger of the context is not used, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(ctxBuf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "info" || entry["http.method"] != "GET" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+}
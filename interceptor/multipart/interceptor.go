@@ -1,142 +1,148 @@
 package easyhttpmultipart
 
 import (
-	"fmt"
+	"bytes"
 	"io"
+	"io/ioutil"
+	"mime"
 	"mime/multipart"
-	"net/http"
 	"net/textproto"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 
-	"github.com/soyacen/bytebufferpool"
 	"github.com/soyacen/goutils/ioutils"
 	"github.com/soyacen/goutils/stringutils"
 
 	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/bodyutils"
 )
 
-type FormData struct {
-	// fieldName is form field name
-	fieldName string
-	// data is text form
-	data string
-	// filepath file form,
-	filepath string
-	// fileContent is happy as long as you pass it a io.ReadCloser (which most file use anyways)
-	fileContent io.ReadCloser
-	// fileMime represents which mimetime should be sent along with the file.
-	// When empty, defaults to application/octet-stream
-	fileMime string
+const (
+	kFormDataSubtype = "form-data"
+	kMixedSubtype    = "mixed"
+	kRelatedSubtype  = "related"
+
+	kJsonContentType        = "application/json"
+	kOctetStreamContentType = "application/octet-stream"
+)
+
+// Interceptor sends parts as a multipart/form-data body.
+func Interceptor(parts ...*Part) easyhttp.Interceptor {
+	return Multipart(parts)
 }
 
-func NewFile(fieldName string, filepath string, fileContent io.ReadCloser, fileMime string) *FormData {
-	return &FormData{
-		fieldName:   fieldName,
-		filepath:    filepath,
-		fileContent: fileContent,
-		fileMime:    fileMime,
-	}
+// Streaming sends parts as a multipart/form-data body without buffering it, see WithStreaming.
+func Streaming(parts ...*Part) easyhttp.Interceptor {
+	return Multipart(parts, WithStreaming())
 }
 
-func NewData(fieldName string, data string) *FormData {
-	return &FormData{
-		fieldName: fieldName,
-		data:      data,
-	}
+// Mixed sends parts as a multipart/mixed body.
+func Mixed(parts ...*Part) easyhttp.Interceptor {
+	return Multipart(parts, WithMixed())
+}
+
+// Related sends parts as a multipart/related body, the first part is the root and its content type is rootType.
+func Related(rootType string, parts ...*Part) easyhttp.Interceptor {
+	return Multipart(parts, WithRelated(rootType))
 }
 
-func Interceptor(formData ...*FormData) easyhttp.Interceptor {
+// Multipart sends parts as a multipart body, form-data by default.
+func Multipart(parts []*Part, opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		for _, file := range formData {
-			if stringutils.IsNotEmpty(file.data) {
-				continue
-			}
-			if file.fileContent != nil {
-				continue
+		boundary := o.boundary
+		if stringutils.IsBlank(boundary) {
+			boundary = multipart.NewWriter(ioutil.Discard).Boundary()
+		}
+		params := map[string]string{"boundary": boundary}
+		for key, value := range o.params {
+			params[key] = value
+		}
+		contentType := mime.FormatMediaType("multipart/"+o.subtype, params)
+
+		if o.streaming {
+			open := func() (io.ReadCloser, error) {
+				pr, pw := io.Pipe()
+				go func() {
+					_ = pw.CloseWithError(writeParts(pw, boundary, o.subtype, parts))
+				}()
+				return pr, nil
 			}
-			// open file by filepath
-			if stringutils.IsNotBlank(file.filepath) {
-				fd, err := os.Open(file.filepath)
-				if err != nil {
-					return nil, err
-				}
-				file.fileContent = fd
+			var getBody func() (io.ReadCloser, error)
+			if rewindable(parts) {
+				getBody = open
 			}
+			// the pipe is opened on the first read, a request that is never sent leaves no goroutine
+			bodyutils.SetStream(req.RawRequest(), bodyutils.LazyBody(open), getBody, -1, contentType)
+			return do(cli, req)
 		}
 
-		requestBody := bytebufferpool.Get()
-
-		multipartWriter := multipart.NewWriter(requestBody)
-		defer ioutils.CloseThrowError(multipartWriter, &err)
-
-		for i, f := range formData {
-			fieldName := f.fieldName
-			if f.fileContent != nil {
-				// generate default field name
-				if stringutils.IsBlank(fieldName) {
-					if len(formData) > 1 {
-						fieldName = strings.Join([]string{"file", strconv.Itoa(i + 1)}, "")
-					} else {
-						fieldName = "file"
-					}
-				}
-				// write file
-				err = writeFile(fieldName, f, multipartWriter)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				// write data
-				err = multipartWriter.WriteField(fieldName, f.data)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-		}
-		if req.RawRequest().Header == nil {
-			req.RawRequest().Header = make(http.Header)
+		// not pooled, GetBody replays the bytes after the interceptor returns, e.g. on redirects
+		var requestBody bytes.Buffer
+		if err = writeParts(&requestBody, boundary, o.subtype, parts); err != nil {
+			return nil, err
 		}
-		req.RawRequest().Header.Add("Content-Type", multipartWriter.FormDataContentType())
-
+		bodyutils.SetBytes(req.RawRequest(), requestBody.Bytes(), contentType)
 		return do(cli, req)
 	}
 }
 
-func writeFile(fieldName string, f *FormData, multipartWriter *multipart.Writer) (err error) {
-	var filename string
-	if stringutils.IsNotBlank(f.filepath) {
-		filename = filepath.Base(filename)
-	} else {
-		filename = "filename"
+// writeParts writes all parts to w and closes the multipart body.
+func writeParts(w io.Writer, boundary string, subtype string, parts []*Part) error {
+	multipartWriter := multipart.NewWriter(w)
+	if err := multipartWriter.SetBoundary(boundary); err != nil {
+		return err
 	}
-
-	var writer io.Writer
-
-	if stringutils.IsNotBlank(f.fileMime) {
-		h := make(textproto.MIMEHeader)
-		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(fieldName), escapeQuotes(filename)))
-		h.Set("Content-Type", f.fileMime)
-		writer, err = multipartWriter.CreatePart(h)
-	} else {
-		writer, err = multipartWriter.CreateFormFile(fieldName, filename)
+	for i, p := range parts {
+		fieldName := p.fieldName
+		// generate default field name
+		if stringutils.IsBlank(fieldName) && p.isFile() {
+			if len(parts) > 1 {
+				fieldName = "file" + strconv.Itoa(i+1)
+			} else {
+				fieldName = "file"
+			}
+		}
+		if err := writePart(multipartWriter, p.mimeHeader(subtype, fieldName), p); err != nil {
+			closeReaders(parts[i+1:])
+			return err
+		}
 	}
+	return multipartWriter.Close()
+}
 
+func writePart(multipartWriter *multipart.Writer, header textproto.MIMEHeader, p *Part) (err error) {
+	content, err := p.content()
 	if err != nil {
 		return err
 	}
-
-	if _, err = io.Copy(writer, f.fileContent); err != nil && err != io.EOF {
+	// the content is closed whatever happens next
+	if closer, ok := content.(io.Closer); ok {
+		defer ioutils.CloseThrowError(closer, &err)
+	}
+	writer, err := multipartWriter.CreatePart(header)
+	if err != nil {
 		return err
 	}
-	return f.fileContent.Close()
+	_, err = io.Copy(writer, content)
+	return err
 }
 
-var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+// closeReaders closes the stream parts that will not be written.
+func closeReaders(parts []*Part) {
+	for _, p := range parts {
+		if closer, ok := p.reader.(io.Closer); ok && p.take() {
+			_ = closer.Close()
+		}
+	}
+}
 
-func escapeQuotes(s string) string {
-	return quoteEscaper.Replace(s)
+// rewindable reports whether all parts can be written again.
+func rewindable(parts []*Part) bool {
+	for _, p := range parts {
+		if !p.rewindable() {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,180 @@
+package easyhttpmultipart
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpretry "github.com/soyacen/easyhttp/interceptor/retry"
+)
+
+type receivedPart struct {
+	name        string
+	filename    string
+	contentType string
+	header      string
+	content     string
+}
+
+func newServer(t *testing.T, mediaType *string, received *[]receivedPart) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mt, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		*mediaType = mt
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := ioutil.ReadAll(part)
+			*received = append(*received, receivedPart{
+				name:        part.FormName(),
+				filename:    part.FileName(),
+				contentType: part.Header.Get("Content-Type"),
+				header:      part.Header.Get("X-Part"),
+				content:     string(data),
+			})
+		}
+	}))
+}
+
+func TestFormData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "easyhttp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.txt")
+	if err := ioutil.WriteFile(path, []byte("file content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interceptor := range []func(parts ...*Part) easyhttp.Interceptor{Interceptor, Streaming} {
+		var mediaType string
+		var received []receivedPart
+		server := newServer(t, &mediaType, &received)
+		client := easyhttp.NewClient()
+		_, err = client.Post(context.Background(), server.URL, interceptor(
+			NewData("name", "easyhttp"),
+			NewFile("report", path, nil, "text/plain"),
+			NewBytes("raw", "raw.bin", []byte{1, 2, 3}, ""),
+			NewBytes("empty", "empty.bin", nil, ""),
+			NewReader("stream", "stream.txt", strings.NewReader("streamed"), "").SetHeader("X-Part", "yes"),
+			NewJSON("meta", map[string]string{"k": "v"}),
+		))
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mediaType != "multipart/form-data" {
+			t.Fatalf("unexpected media type %s", mediaType)
+		}
+		expected := []receivedPart{
+			{name: "name", content: "easyhttp"},
+			{name: "report", filename: "report.txt", contentType: "text/plain", content: "file content"},
+			{name: "raw", filename: "raw.bin", contentType: "application/octet-stream", content: "\x01\x02\x03"},
+			{name: "empty", filename: "empty.bin", contentType: "application/octet-stream"},
+			{name: "stream", filename: "stream.txt", contentType: "application/octet-stream", header: "yes", content: "streamed"},
+			{name: "meta", contentType: "application/json", content: `{"k":"v"}`},
+		}
+		if len(received) != len(expected) {
+			t.Fatalf("expected %d parts, got %d", len(expected), len(received))
+		}
+		for i := range expected {
+			if received[i] != expected[i] {
+				t.Errorf("part %d, expected %+v, got %+v", i, expected[i], received[i])
+			}
+		}
+	}
+}
+
+func TestRelated(t *testing.T) {
+	var mediaType string
+	var received []receivedPart
+	server := newServer(t, &mediaType, &received)
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	_, err := client.Post(context.Background(), server.URL, Related("application/json",
+		NewJSON("", map[string]string{"title": "doc"}).SetHeader("Content-ID", "<root>"),
+		NewBytes("", "image.png", []byte("png"), "image/png"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/related" {
+		t.Fatalf("unexpected media type %s", mediaType)
+	}
+	if len(received) != 2 || received[0].content != `{"title":"doc"}` || received[1].filename != "image.png" {
+		t.Fatalf("unexpected parts %+v", received)
+	}
+}
+
+func TestRedirectReplaysBody(t *testing.T) {
+	var mediaType string
+	var received []receivedPart
+	target := newServer(t, &mediaType, &received)
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	client := easyhttp.NewClient()
+	_, err := client.Post(context.Background(), redirect.URL, Interceptor(NewData("name", "easyhttp")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0].content != "easyhttp" {
+		t.Fatalf("unexpected parts %+v", received)
+	}
+}
+
+func TestReaderPartRetry(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		var mediaType string
+		var received []receivedPart
+		target := newServer(t, &mediaType, &received)
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			target.Config.Handler.ServeHTTP(w, r)
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+
+		var opts []Option
+		if streaming {
+			opts = append(opts, WithStreaming())
+		}
+		client := easyhttp.NewClient(easyhttp.WithChainInterceptor(easyhttpretry.Interceptor(
+			easyhttpretry.WithMaxAttempts(1),
+			easyhttpretry.WithIdempotencyKey(nil),
+		)))
+		part := NewReader("file", "a.txt", strings.NewReader("content"), "text/plain")
+		_, err := client.Post(context.Background(), server.URL, Multipart([]*Part{part}, opts...))
+		server.Close()
+		target.Close()
+		if !errors.Is(err, ErrPartSent) {
+			t.Fatalf("streaming %v: expected ErrPartSent, got %v", streaming, err)
+		}
+		if len(received) != 1 || received[0].content != "content" {
+			t.Fatalf("streaming %v: unexpected parts %+v", streaming, received)
+		}
+	}
+}
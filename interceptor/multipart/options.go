@@ -0,0 +1,71 @@
+package easyhttpmultipart
+
+type options struct {
+	subtype   string
+	params    map[string]string
+	boundary  string
+	streaming bool
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		subtype: kFormDataSubtype,
+		params:  make(map[string]string),
+	}
+}
+
+type Option func(o *options)
+
+// WithFormData sends a multipart/form-data body, it is the default.
+func WithFormData() Option {
+	return func(o *options) {
+		o.subtype = kFormDataSubtype
+	}
+}
+
+// WithMixed sends a multipart/mixed body.
+func WithMixed() Option {
+	return func(o *options) {
+		o.subtype = kMixedSubtype
+	}
+}
+
+// WithRelated sends a multipart/related body (RFC 2387),
+// rootType is the content type of the root part, which is the first part.
+func WithRelated(rootType string) Option {
+	return func(o *options) {
+		o.subtype = kRelatedSubtype
+		o.params["type"] = rootType
+	}
+}
+
+// WithParam adds a parameter to the Content-Type of the body, e.g. "start" of multipart/related.
+func WithParam(key, value string) Option {
+	return func(o *options) {
+		o.params[key] = value
+	}
+}
+
+// WithBoundary sets the boundary, instead of a random one.
+func WithBoundary(boundary string) Option {
+	return func(o *options) {
+		o.boundary = boundary
+	}
+}
+
+// WithStreaming writes the body through an io.Pipe while the request is sent,
+// so file parts are never buffered in memory. The body is sent chunked.
+//
+// If no part is created by NewReader, the body is rewound by writing the parts again,
+// so the request can be retried or redirected without buffering.
+func WithStreaming() Option {
+	return func(o *options) {
+		o.streaming = true
+	}
+}
@@ -0,0 +1,206 @@
+package easyhttpmultipart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/soyacen/goutils/stringutils"
+)
+
+// ErrPartSent is returned when a reader part is written again, e.g. by a retry or a redirect,
+// since its content was already consumed.
+var ErrPartSent = errors.New("easyhttpmultipart: the reader part was already sent, it can not be sent again")
+
+// partKind is the kind of content of a part.
+type partKind int
+
+const (
+	kDataPart partKind = iota
+	kFilePart
+	kBytesPart
+	kReaderPart
+	kJSONPart
+)
+
+// Part is a part of a multipart body.
+type Part struct {
+	// kind tells which field holds the content
+	kind partKind
+	// fieldName is form field name
+	fieldName string
+	// filename is the filename of a file part, when empty, the base of filepath is used
+	filename string
+	// data is text form
+	data string
+	// bytes is the content of a part given in memory
+	bytes []byte
+	// filepath is the path of a file part, the file is opened each time the part is written
+	filepath string
+	// reader is the content of a part given as a stream, it can be written only once.
+	// It is closed after written if it is an io.Closer.
+	reader io.Reader
+	// taken is set once reader is written or closed
+	taken int32
+	// obj is marshaled to JSON when the part is written
+	obj interface{}
+	// contentType represents which mimetime should be sent along with the part.
+	// When empty, defaults to application/octet-stream for files
+	contentType string
+	// header is the additional header of the part
+	header textproto.MIMEHeader
+}
+
+// FormData is the former name of Part.
+//
+// Deprecated: use Part.
+type FormData = Part
+
+// NewData creates a text form field.
+func NewData(fieldName string, data string) *Part {
+	return &Part{
+		kind:      kDataPart,
+		fieldName: fieldName,
+		data:      data,
+	}
+}
+
+// NewFile creates a file part.
+// fileContent is the content of the file, when nil, the file at filepath is opened.
+// A non nil fileContent can be written only once, see NewReader.
+func NewFile(fieldName string, filepath string, fileContent io.ReadCloser, fileMime string) *Part {
+	p := &Part{
+		kind:        kFilePart,
+		fieldName:   fieldName,
+		filepath:    filepath,
+		contentType: fileMime,
+	}
+	if fileContent != nil {
+		p.kind = kReaderPart
+		p.reader = fileContent
+	}
+	return p
+}
+
+// NewBytes creates a part with the content data.
+func NewBytes(fieldName string, filename string, data []byte, contentType string) *Part {
+	return &Part{
+		kind:        kBytesPart,
+		fieldName:   fieldName,
+		filename:    filename,
+		bytes:       data,
+		contentType: contentType,
+	}
+}
+
+// NewReader creates a part streamed from r, it can not be rewound:
+// writing the part again, e.g. on a retry or a redirect, fails with ErrPartSent.
+func NewReader(fieldName string, filename string, r io.Reader, contentType string) *Part {
+	return &Part{
+		kind:        kReaderPart,
+		fieldName:   fieldName,
+		filename:    filename,
+		reader:      r,
+		contentType: contentType,
+	}
+}
+
+// NewJSON creates a part with the JSON encoding of obj, the content type is application/json.
+func NewJSON(fieldName string, obj interface{}) *Part {
+	return &Part{
+		kind:        kJSONPart,
+		fieldName:   fieldName,
+		obj:         obj,
+		contentType: kJsonContentType,
+	}
+}
+
+// SetHeader sets a header of the part, it overrides the generated Content-Disposition and Content-Type.
+func (p *Part) SetHeader(key, value string) *Part {
+	if p.header == nil {
+		p.header = make(textproto.MIMEHeader)
+	}
+	p.header.Set(key, value)
+	return p
+}
+
+func (p *Part) isFile() bool {
+	return p.kind != kDataPart && p.kind != kJSONPart
+}
+
+// rewindable reports whether the part can be written again.
+func (p *Part) rewindable() bool {
+	return p.kind != kReaderPart
+}
+
+// take reports whether the reader of the part is not written nor closed yet, and marks it so.
+func (p *Part) take() bool {
+	return atomic.CompareAndSwapInt32(&p.taken, 0, 1)
+}
+
+func (p *Part) content() (io.Reader, error) {
+	switch p.kind {
+	case kDataPart:
+		return strings.NewReader(p.data), nil
+	case kJSONPart:
+		data, err := json.Marshal(p.obj)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	case kReaderPart:
+		if !p.take() {
+			return nil, ErrPartSent
+		}
+		return p.reader, nil
+	case kBytesPart:
+		return bytes.NewReader(p.bytes), nil
+	default:
+		return os.Open(p.filepath)
+	}
+}
+
+// mimeHeader returns the header of the part in a multipart body of subtype.
+func (p *Part) mimeHeader(subtype string, fieldName string) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	filename := p.filename
+	if stringutils.IsBlank(filename) && stringutils.IsNotBlank(p.filepath) {
+		filename = filepath.Base(p.filepath)
+	}
+	if subtype == kFormDataSubtype {
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(fieldName))
+		if p.isFile() {
+			if stringutils.IsBlank(filename) {
+				filename = "filename"
+			}
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+		}
+		h.Set("Content-Disposition", disposition)
+	} else if stringutils.IsNotBlank(filename) {
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, escapeQuotes(filename)))
+	}
+	contentType := p.contentType
+	if stringutils.IsBlank(contentType) && p.isFile() {
+		contentType = kOctetStreamContentType
+	}
+	if stringutils.IsNotBlank(contentType) {
+		h.Set("Content-Type", contentType)
+	}
+	for key, values := range p.header {
+		h[key] = values
+	}
+	return h
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
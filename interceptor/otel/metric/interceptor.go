@@ -0,0 +1,234 @@
+package otelmetric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/unit"
+
+	"github.com/soyacen/easyhttp"
+)
+
+const instrumentationName = "github.com/soyacen/easyhttp/interceptor/otel/metric"
+
+const (
+	kMethodKey      = attribute.Key("http.request.method")
+	kServerKey      = attribute.Key("server.address")
+	kRouteKey       = attribute.Key("http.route")
+	kStatusClassKey = attribute.Key("http.response.status_class")
+	kErrorTypeKey   = attribute.Key("error.type")
+)
+
+// Interceptor records the client metrics of the HTTP semantic conventions:
+// http.client.request.duration, http.client.request.body.size, http.client.response.body.size
+// and http.client.active_requests.
+//
+// The duration is recorded for each call, the body sizes for each request sent, e.g. each attempt of a retry.
+// They are the bytes read from the bodies, so chunked bodies are recorded too,
+// the size of a response body is recorded once it is read to the end or closed.
+//
+// With this version of the metric API, an instrument cannot carry its bucket boundaries, they are configured
+// in the aggregator selector of the SDK. The boundaries advised are, in seconds for the duration:
+//
+//	0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10
+//
+// and in bytes for the body sizes:
+//
+//	0, 100, 1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20, 100 << 20
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	m := newMetrics(o.meterProvider.Meter(instrumentationName))
+	countBodies := easyhttp.Innermost(m.countBodies(o))
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		rawRequest := req.RawRequest()
+		ctx := req.Context()
+		attrs := []attribute.KeyValue{
+			kMethodKey.String(method(rawRequest)),
+			kServerKey.String(rawRequest.URL.Host),
+		}
+		m.activeRequests.Add(ctx, 1, attrs...)
+		start := time.Now()
+		reply, err := countBodies(cli, req, do)
+		elapsed := time.Since(start)
+		m.activeRequests.Add(ctx, -1, attrs...)
+		// the route is set by the path param interceptors, they may be inner ones
+		attrs = appendResult(attrs, o.routeFunc(req.RawRequest()), reply, err)
+		m.duration.Record(ctx, elapsed.Seconds(), attrs...)
+		return reply, err
+	}
+}
+
+// appendResult appends the route and the outcome of a call to attrs.
+func appendResult(attrs []attribute.KeyValue, route string, reply *easyhttp.Reply, err error) []attribute.KeyValue {
+	if route != "" {
+		attrs = append(attrs, kRouteKey.String(route))
+	}
+	var statusErr *easyhttp.StatusError
+	switch {
+	case reply != nil && reply.RawResponse() != nil:
+		attrs = append(attrs, kStatusClassKey.String(statusClass(reply.RawResponse().StatusCode)))
+	case errors.As(err, &statusErr):
+		attrs = append(attrs, kStatusClassKey.String(statusClass(statusErr.StatusCode)))
+	}
+	if err != nil {
+		attrs = append(attrs, kErrorTypeKey.String(errorType(err)))
+	}
+	return attrs
+}
+
+type metrics struct {
+	duration       metric.Float64Histogram
+	requestSize    metric.Int64Histogram
+	responseSize   metric.Int64Histogram
+	activeRequests metric.Int64UpDownCounter
+}
+
+func newMetrics(meter metric.Meter) *metrics {
+	m := &metrics{}
+	var err error
+	m.duration, err = meter.NewFloat64Histogram("http.client.request.duration",
+		metric.WithUnit(unit.Unit("s")),
+		metric.WithDescription("Duration of HTTP client requests."))
+	handle(err)
+	m.requestSize, err = meter.NewInt64Histogram("http.client.request.body.size",
+		metric.WithUnit(unit.Bytes),
+		metric.WithDescription("Size of HTTP client request bodies."))
+	handle(err)
+	m.responseSize, err = meter.NewInt64Histogram("http.client.response.body.size",
+		metric.WithUnit(unit.Bytes),
+		metric.WithDescription("Size of HTTP client response bodies."))
+	handle(err)
+	m.activeRequests, err = meter.NewInt64UpDownCounter("http.client.active_requests",
+		metric.WithUnit(unit.Unit("{request}")),
+		metric.WithDescription("Number of active HTTP requests."))
+	handle(err)
+	return m
+}
+
+// countBodies counts the bytes of the request body sent and of the response body received,
+// it is installed right before the request is sent so the bodies set by every interceptor are counted.
+func (m *metrics) countBodies(o *options) easyhttp.Interceptor {
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		rawRequest := req.RawRequest()
+		ctx := req.Context()
+		body, getBody := rawRequest.Body, rawRequest.GetBody
+		requestSize := new(int64)
+		if body != nil && body != http.NoBody {
+			rawRequest.Body = &countingBody{ReadCloser: body, n: requestSize}
+		}
+		if getBody != nil {
+			// the body is rewound by the transport or by a redirect, only the last one is counted
+			rawRequest.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil || body == nil || body == http.NoBody {
+					return body, err
+				}
+				atomic.StoreInt64(requestSize, 0)
+				return &countingBody{ReadCloser: body, n: requestSize}, nil
+			}
+		}
+		reply, err := do(cli, req)
+		rawRequest.Body, rawRequest.GetBody = body, getBody
+
+		attrs := appendResult([]attribute.KeyValue{
+			kMethodKey.String(method(rawRequest)),
+			kServerKey.String(rawRequest.URL.Host),
+		}, o.routeFunc(rawRequest), reply, err)
+		if size := atomic.LoadInt64(requestSize); size > 0 {
+			m.requestSize.Record(ctx, size, attrs...)
+		}
+		if reply == nil || reply.RawResponse() == nil {
+			return reply, err
+		}
+		response := reply.RawResponse()
+		if response.Body == nil || response.Body == http.NoBody {
+			m.responseSize.Record(ctx, 0, attrs...)
+			return reply, err
+		}
+		response.Body = &countingBody{ReadCloser: response.Body, n: new(int64), done: func(size int64) {
+			m.responseSize.Record(ctx, size, attrs...)
+		}}
+		return reply, err
+	}
+}
+
+// countingBody counts the bytes read from a body,
+// done is called once with the count when the body is read to the end or closed.
+type countingBody struct {
+	io.ReadCloser
+	n    *int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) finish() {
+	if b.done != nil {
+		b.once.Do(func() { b.done(atomic.LoadInt64(b.n)) })
+	}
+}
+
+func handle(err error) {
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+func method(rawRequest *http.Request) string {
+	if rawRequest.Method == "" {
+		return http.MethodGet
+	}
+	return rawRequest.Method
+}
+
+// statusClass returns 2xx, 3xx, 4xx or 5xx.
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return strconv.Itoa(statusCode)
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// errorType returns a low cardinality description of err.
+func errorType(err error) string {
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.StatusCode)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Err != nil {
+		err = urlErr.Err
+	}
+	return fmt.Sprintf("%T", err)
+}
@@ -0,0 +1,93 @@
+package otelmetric
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/metric/metrictest"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpreqbody "github.com/soyacen/easyhttp/interceptor/reqbody"
+	easyhttpurl "github.com/soyacen/easyhttp/interceptor/url"
+)
+
+func TestInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+	defer server.Close()
+
+	provider := metrictest.NewMeterProvider()
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(WithMeterProvider(provider))))
+	reply, err := client.Get(context.Background(), server.URL+"/users/:id", easyhttpurl.PathParam("id", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the size of the response body is recorded once it is read
+	if _, err := reply.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+
+	measured := make(map[string]metrictest.Measured)
+	for _, m := range metrictest.AsStructs(provider.MeasurementBatches) {
+		measured[m.Name] = m
+	}
+	for _, name := range []string{"http.client.request.duration", "http.client.response.body.size", "http.client.active_requests"} {
+		if _, ok := measured[name]; !ok {
+			t.Fatalf("%s is not recorded", name)
+		}
+	}
+	duration := measured["http.client.request.duration"]
+	if duration.Labels[kStatusClassKey].AsString() != "4xx" ||
+		duration.Labels[kRouteKey].AsString() != "/users/:id" ||
+		duration.Labels[kMethodKey].AsString() != http.MethodGet {
+		t.Fatalf("unexpected labels %v", duration.Labels)
+	}
+	size := measured["http.client.response.body.size"].Number
+	if size := size.AsInt64(); size != int64(len("not found")) {
+		t.Fatalf("unexpected response size %d", size)
+	}
+}
+
+func TestChunkedBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 {
+			t.Errorf("the request body is not chunked, length %d", r.ContentLength)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+		w.(http.Flusher).Flush()
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	provider := metrictest.NewMeterProvider()
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(WithMeterProvider(provider))))
+	reply, err := client.Post(context.Background(), server.URL, easyhttpreqbody.Stream(strings.NewReader("hello"), -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.RawResponse().ContentLength != -1 {
+		t.Fatalf("the response body is not chunked, length %d", reply.RawResponse().ContentLength)
+	}
+	if _, err := reply.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	measured := make(map[string]metrictest.Measured)
+	for _, m := range metrictest.AsStructs(provider.MeasurementBatches) {
+		measured[m.Name] = m
+	}
+	requestSize := measured["http.client.request.body.size"].Number
+	if size := requestSize.AsInt64(); size != int64(len("hello")) {
+		t.Fatalf("unexpected request size %d", size)
+	}
+	responseSize := measured["http.client.response.body.size"].Number
+	if size := responseSize.AsInt64(); size != int64(len("hellohello")) {
+		t.Fatalf("unexpected response size %d", size)
+	}
+}
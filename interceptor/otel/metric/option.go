@@ -0,0 +1,49 @@
+package otelmetric
+
+import (
+	"net/http"
+
+	"github.com/soyacen/easyhttp"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+)
+
+// RouteFunc returns the route template of a request, e.g. /users/:id, "" if unknown.
+type RouteFunc func(rawRequest *http.Request) string
+
+type options struct {
+	meterProvider metric.MeterProvider
+	routeFunc     RouteFunc
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+type Option func(o *options)
+
+func defaultOptions() *options {
+	return &options{
+		meterProvider: global.GetMeterProvider(),
+		routeFunc:     easyhttp.RouteOf,
+	}
+}
+
+// WithMeterProvider sets the meter provider, default is the global one.
+func WithMeterProvider(meterProvider metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = meterProvider
+	}
+}
+
+// WithRouteFunc sets the function returning the route template recorded as http.route,
+// default is easyhttp.RouteOf.
+// The raw url is never recorded, to bound the cardinality of the metrics.
+func WithRouteFunc(routeFunc RouteFunc) Option {
+	return func(o *options) {
+		o.routeFunc = routeFunc
+	}
+}
@@ -1,6 +1,11 @@
 package oteltrace
 
 import (
+	"context"
+	"net/http/httptrace"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
@@ -9,13 +14,15 @@ import (
 	"github.com/soyacen/easyhttp"
 )
 
+const kRetryAttemptKey = attribute.Key("http.retry.attempt")
+
 func Interceptor(opts ...Option) easyhttp.Interceptor {
 	o := defaultOptions()
 	o.apply(opts...)
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
 		ctx, span := o.tracer.Start(
 			req.Context(),
-			o.spanNameFunc(req.RawRequest().URL),
+			o.spanName(req),
 			trace.WithSpanKind(trace.SpanKindClient),
 			trace.WithAttributes(
 				semconv.RPCSystemKey.String("http"),
@@ -24,9 +31,28 @@ func Interceptor(opts ...Option) easyhttp.Interceptor {
 				semconv.HTTPFlavorKey.String(req.RawRequest().Proto),
 			),
 		)
+		// inside the retry interceptor, each attempt is a span
+		if attempt, ok := easyhttp.AttemptFromContext(req.Context()); ok {
+			span.SetAttributes(kRetryAttemptKey.Int(int(attempt)))
+		}
+		var timings *easyhttp.Timings
+		if o.timings {
+			timings = easyhttp.NewTimings()
+			ctx = httptrace.WithClientTrace(ctx, timings.ClientTrace())
+		}
 		o.propagator.Inject(ctx, propagation.HeaderCarrier(req.RawRequest().Header))
 		req.SetContext(ctx)
 		reply, err = do(cli, req)
+		if timings != nil {
+			addTimings(span, timings)
+		}
+		// the route is set by the path param interceptors, they may be inner ones
+		if route := req.Route(); route != "" {
+			span.SetAttributes(semconv.HTTPRouteKey.String(route))
+			if o.spanNameFunc == nil {
+				span.SetName(route)
+			}
+		}
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
@@ -42,3 +68,63 @@ func Interceptor(opts ...Option) easyhttp.Interceptor {
 		return reply, err
 	}
 }
+
+func (o *options) spanName(req *easyhttp.Request) string {
+	if o.spanNameFunc != nil {
+		return o.spanNameFunc(req.RawRequest().URL)
+	}
+	if route := req.Route(); route != "" {
+		return route
+	}
+	return req.RawRequest().URL.EscapedPath()
+}
+
+// addTimings adds the events and attributes of the connection timings to span.
+func addTimings(span trace.Span, timings *easyhttp.Timings) {
+	timings = timings.Snapshot()
+	events := []struct {
+		name string
+		at   time.Time
+	}{
+		{"dns.start", timings.DNSStart},
+		{"dns.done", timings.DNSDone},
+		{"connect.start", timings.ConnectStart},
+		{"connect.done", timings.ConnectDone},
+		{"tls.start", timings.TLSHandshakeStart},
+		{"tls.done", timings.TLSHandshakeDone},
+		{"got_conn", timings.GotConn},
+		{"wrote_request", timings.WroteRequest},
+		{"first_response_byte", timings.GotFirstResponseByte},
+	}
+	for _, event := range events {
+		if !event.at.IsZero() {
+			span.AddEvent(event.name, trace.WithTimestamp(event.at))
+		}
+	}
+	span.SetAttributes(
+		attribute.Bool("http.conn.reused", timings.ConnReused),
+		attribute.Bool("http.conn.was_idle", timings.ConnWasIdle),
+		attribute.Int64("http.conn.idle_time_ms", timings.ConnIdleTime.Milliseconds()),
+		attribute.Int64("http.time_to_first_byte_ms", timings.TimeToFirstByte().Milliseconds()),
+	)
+}
+
+// RetryEvent adds an event for each retry to the span of ctx,
+// it is a hook for easyhttpretry.WithOnRetry when the retry interceptor runs inside this interceptor.
+func RetryEvent(ctx context.Context, attempt uint, reply *easyhttp.Reply, err error, wait time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		kRetryAttemptKey.Int(int(attempt)),
+		attribute.String("http.retry.wait", wait.String()),
+	}
+	if reply != nil && reply.RawResponse() != nil {
+		attrs = append(attrs, semconv.HTTPStatusCodeKey.Int(reply.RawResponse().StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("exception.message", err.Error()))
+	}
+	span.AddEvent("retry", trace.WithAttributes(attrs...))
+}
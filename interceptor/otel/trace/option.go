@@ -14,6 +14,7 @@ type options struct {
 	propagator   propagation.TextMapPropagator
 	tracer       trace.Tracer
 	spanNameFunc SpanNameFunc
+	timings      bool
 }
 
 func (o *options) apply(opts ...Option) {
@@ -26,9 +27,8 @@ type Option func(o *options)
 
 func defaultOptions() *options {
 	return &options{
-		tracer:       otel.Tracer(""),
-		propagator:   otel.GetTextMapPropagator(),
-		spanNameFunc: func(u *url.URL) string { return u.EscapedPath() },
+		tracer:     otel.Tracer(""),
+		propagator: otel.GetTextMapPropagator(),
 	}
 }
 
@@ -44,8 +44,18 @@ func WithPropagator(propagator propagation.TextMapPropagator) Option {
 	}
 }
 
+// WithSpanNameFunc sets the function naming the spans, by default a span is named
+// after the route of the request, e.g. /users/:id, or its escaped path if it has none.
 func WithSpanNameFunc(f func(u *url.URL) string) Option {
 	return func(options *options) {
 		options.spanNameFunc = f
 	}
 }
+
+// WithTimings adds the connection timings of the request to the span, as events
+// (DNS lookup, connection, TLS handshake, first response byte) and attributes (connection reuse).
+func WithTimings() Option {
+	return func(o *options) {
+		o.timings = true
+	}
+}
@@ -0,0 +1,44 @@
+package easyhttpprogress
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Interceptor reports the progress of the request body and of the response body.
+//
+// The request body must be set by an interceptor before this one,
+// and an interceptor consuming the response body, like easyhttpdownload, must be put before this one too.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		rawRequest := req.RawRequest()
+		if o.upload && rawRequest.Body != nil && rawRequest.Body != http.NoBody {
+			t := newTracker(Upload, rawRequest.ContentLength, o)
+			rawRequest.Body = &reader{body: rawRequest.Body, tracker: t}
+			if getBody := rawRequest.GetBody; getBody != nil {
+				rawRequest.GetBody = func() (io.ReadCloser, error) {
+					body, err := getBody()
+					if err != nil {
+						return nil, err
+					}
+					t.reset()
+					return &reader{body: body, tracker: t}, nil
+				}
+			}
+		}
+		reply, err = do(cli, req)
+		if !o.download || reply == nil || reply.RawResponse() == nil {
+			return reply, err
+		}
+		rawResponse := reply.RawResponse()
+		if rawResponse.Body != nil && rawResponse.Body != http.NoBody {
+			t := newTracker(Download, rawResponse.ContentLength, o)
+			rawResponse.Body = &reader{body: rawResponse.Body, tracker: t}
+		}
+		return reply, err
+	}
+}
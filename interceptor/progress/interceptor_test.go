@@ -0,0 +1,55 @@
+package easyhttpprogress
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpreqbody "github.com/soyacen/easyhttp/interceptor/reqbody"
+)
+
+func TestProgress(t *testing.T) {
+	body := strings.Repeat("easyhttp", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	final := make(map[Direction]Progress)
+	client := easyhttp.NewClient()
+	reply, err := client.Post(context.Background(), server.URL,
+		easyhttpreqbody.Text(body),
+		Interceptor(WithCallback(func(p Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			if p.Done {
+				final[p.Direction] = p
+			}
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reply.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, direction := range []Direction{Upload, Download} {
+		p, ok := final[direction]
+		if !ok {
+			t.Fatalf("no final %s progress", direction)
+		}
+		if p.Transferred != int64(len(body)) || p.Total != int64(len(body)) || p.Percent() != 100 {
+			t.Fatalf("unexpected %s progress %+v", direction, p)
+		}
+	}
+}
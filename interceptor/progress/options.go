@@ -0,0 +1,82 @@
+package easyhttpprogress
+
+import "time"
+
+// Callback is called with the progress of a transfer, from the goroutine reading the body.
+type Callback func(p Progress)
+
+type options struct {
+	upload   bool
+	download bool
+	interval time.Duration
+	callback Callback
+	ch       chan<- Progress
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func (o *options) report(p Progress) {
+	if o.callback != nil {
+		o.callback(p)
+	}
+	if o.ch != nil {
+		// never block the transfer, a slow receiver misses some intermediate progress
+		select {
+		case o.ch <- p:
+		default:
+		}
+	}
+}
+
+const defaultInterval = 100 * time.Millisecond
+
+func defaultOptions() *options {
+	return &options{
+		upload:   true,
+		download: true,
+		interval: defaultInterval,
+	}
+}
+
+type Option func(o *options)
+
+// WithCallback sets the callback receiving the progress.
+func WithCallback(callback Callback) Option {
+	return func(o *options) {
+		o.callback = callback
+	}
+}
+
+// WithChannel sends the progress to ch. Sends never block, the progress is dropped if ch is full.
+func WithChannel(ch chan<- Progress) Option {
+	return func(o *options) {
+		o.ch = ch
+	}
+}
+
+// WithInterval sets the minimum interval between two reports of a transfer, the final report is always sent.
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// UploadOnly reports the progress of the request body only.
+func UploadOnly() Option {
+	return func(o *options) {
+		o.upload = true
+		o.download = false
+	}
+}
+
+// DownloadOnly reports the progress of the response body only.
+func DownloadOnly() Option {
+	return func(o *options) {
+		o.upload = false
+		o.download = true
+	}
+}
@@ -0,0 +1,139 @@
+package easyhttpprogress
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Direction is the direction of a transfer.
+type Direction int
+
+const (
+	// Upload is the transfer of the request body.
+	Upload Direction = 1
+	// Download is the transfer of the response body.
+	Download Direction = 2
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Upload:
+		return "upload"
+	case Download:
+		return "download"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress is a snapshot of a transfer.
+type Progress struct {
+	// Direction is the direction of the transfer
+	Direction Direction
+	// Transferred is the number of bytes transferred so far
+	Transferred int64
+	// Total is the number of bytes to transfer, -1 if unknown
+	Total int64
+	// Rate is the average transfer rate in bytes per second
+	Rate float64
+	// ETA is the estimated remaining time, -1 if unknown
+	ETA time.Duration
+	// Elapsed is the time since the transfer started
+	Elapsed time.Duration
+	// Done reports whether the transfer is complete
+	Done bool
+}
+
+// Percent returns the percentage transferred, -1 if the total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Transferred) * 100 / float64(p.Total)
+}
+
+type tracker struct {
+	mu          sync.Mutex
+	direction   Direction
+	total       int64
+	transferred int64
+	start       time.Time
+	lastReport  time.Time
+	done        bool
+	o           *options
+}
+
+func newTracker(direction Direction, total int64, o *options) *tracker {
+	if total <= 0 {
+		total = -1
+	}
+	return &tracker{direction: direction, total: total, o: o}
+}
+
+// reset restarts the transfer, e.g. when a request body is rewound for a retry.
+func (t *tracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transferred = 0
+	t.start = time.Time{}
+	t.done = false
+}
+
+func (t *tracker) add(n int, eof bool) {
+	t.mu.Lock()
+	now := time.Now()
+	if t.start.IsZero() {
+		t.start = now
+	}
+	t.transferred += int64(n)
+	if t.done {
+		t.mu.Unlock()
+		return
+	}
+	t.done = eof || (t.total > 0 && t.transferred >= t.total)
+	if !t.done && now.Sub(t.lastReport) < t.o.interval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastReport = now
+	p := t.snapshot(now)
+	t.mu.Unlock()
+	t.o.report(p)
+}
+
+func (t *tracker) snapshot(now time.Time) Progress {
+	p := Progress{
+		Direction:   t.direction,
+		Transferred: t.transferred,
+		Total:       t.total,
+		ETA:         -1,
+		Elapsed:     now.Sub(t.start),
+		Done:        t.done,
+	}
+	if p.Elapsed > 0 {
+		p.Rate = float64(p.Transferred) / p.Elapsed.Seconds()
+	}
+	if p.Done {
+		p.ETA = 0
+	} else if p.Total > 0 && p.Rate > 0 {
+		p.ETA = time.Duration(float64(p.Total-p.Transferred) / p.Rate * float64(time.Second))
+	}
+	return p
+}
+
+// reader reports the progress of reading body.
+type reader struct {
+	body    io.ReadCloser
+	tracker *tracker
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.tracker.add(n, err == io.EOF)
+	return n, err
+}
+
+func (r *reader) Close() error {
+	return r.body.Close()
+}
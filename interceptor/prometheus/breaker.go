@@ -0,0 +1,49 @@
+package easyhttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	easyhttpbreaker "github.com/soyacen/easyhttp/interceptor/breaker"
+)
+
+// BreakerCollector collects the state of the breakers of a registry,
+// as a gauge per state set to 1 for the current state.
+type BreakerCollector struct {
+	registry *easyhttpbreaker.Registry
+	desc     *prometheus.Desc
+}
+
+// NewBreakerCollector creates a BreakerCollector of registry and registers it.
+func NewBreakerCollector(registry *easyhttpbreaker.Registry, opts ...Option) *BreakerCollector {
+	o := defaultOptions()
+	o.apply(opts...)
+	c := &BreakerCollector{
+		registry: registry,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, o.subsystem, "breaker_state"),
+			"State of the circuit breakers, 1 for the current state.",
+			[]string{"key", "state"}, nil,
+		),
+	}
+	if err := o.registerer.Register(c); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *BreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *BreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, breaker := range c.registry.Breakers() {
+		current := breaker.State()
+		for _, state := range []easyhttpbreaker.State{easyhttpbreaker.Closed, easyhttpbreaker.Open, easyhttpbreaker.HalfOpen} {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, breaker.Key(), state.String())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package easyhttpprometheus
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Metrics holds the collectors of the requests.
+type Metrics struct {
+	o        *options
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	retries  *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors and registers them. Collectors already registered with the same
+// description, e.g. by the metrics of another client, are reused. It panics if the registration fails.
+func NewMetrics(opts ...Option) *Metrics {
+	o := defaultOptions()
+	o.apply(opts...)
+	labels := []string{"method", "host", "route", "code"}
+	m := &Metrics{o: o}
+	m.requests = register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: o.namespace,
+		Subsystem: o.subsystem,
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests, by status code.",
+	}, labels)).(*prometheus.CounterVec)
+	m.duration = register(o.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: o.namespace,
+		Subsystem: o.subsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests.",
+		Buckets:   o.buckets,
+	}, labels)).(*prometheus.HistogramVec)
+	m.inFlight = register(o.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: o.namespace,
+		Subsystem: o.subsystem,
+		Name:      "in_flight_requests",
+		Help:      "Number of HTTP requests in flight.",
+	}, []string{"method", "host"})).(*prometheus.GaugeVec)
+	m.retries = register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: o.namespace,
+		Subsystem: o.subsystem,
+		Name:      "retries_total",
+		Help:      "Number of HTTP requests that are retries of a previous attempt.",
+	}, []string{"method", "host", "route"})).(*prometheus.CounterVec)
+	return m
+}
+
+// Interceptor records the requests.
+// Installed after the retry interceptor, each attempt is recorded and the retries are counted.
+func (m *Metrics) Interceptor() easyhttp.Interceptor {
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		rawRequest := req.RawRequest()
+		method := rawRequest.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		host := rawRequest.URL.Host
+		attempt, _ := easyhttp.AttemptFromContext(req.Context())
+
+		inFlight := m.inFlight.WithLabelValues(method, host)
+		inFlight.Inc()
+		start := time.Now()
+		reply, err := do(cli, req)
+		elapsed := time.Since(start)
+		inFlight.Dec()
+
+		// the route is set by the path param interceptors, they may be inner ones
+		route := m.o.routeFunc(req.RawRequest())
+		if attempt > 0 {
+			m.retries.WithLabelValues(method, host, route).Inc()
+		}
+
+		code := statusCode(reply, err)
+		m.requests.WithLabelValues(method, host, route, code).Inc()
+		m.duration.WithLabelValues(method, host, route, code).Observe(elapsed.Seconds())
+		return reply, err
+	}
+}
+
+// Interceptor records the requests, see Metrics.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	return NewMetrics(opts...).Interceptor()
+}
+
+// statusCode returns the status code label, "error" if there is no response.
+func statusCode(reply *easyhttp.Reply, err error) string {
+	if reply != nil && reply.RawResponse() != nil {
+		return strconv.Itoa(reply.RawResponse().StatusCode)
+	}
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.StatusCode)
+	}
+	return "error"
+}
+
+func register(registerer prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	if err := registerer.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			return alreadyRegistered.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
@@ -0,0 +1,73 @@
+package easyhttpprometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpbreaker "github.com/soyacen/easyhttp/interceptor/breaker"
+	easyhttpretry "github.com/soyacen/easyhttp/interceptor/retry"
+	easyhttpurl "github.com/soyacen/easyhttp/interceptor/url"
+)
+
+func TestInterceptor(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(WithRegisterer(registry))
+	client := easyhttp.NewClient()
+	_, err := client.Get(context.Background(), server.URL+"/users/:id",
+		easyhttpretry.Interceptor(easyhttpretry.WithMaxAttempts(1), easyhttpretry.WithBackoff(func(ctx context.Context, attempt uint) time.Duration { return 0 })),
+		metrics.Interceptor(),
+		easyhttpurl.PathParam("id", "1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	if v := testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "/users/:id", "503")); v != 1 {
+		t.Fatalf("unexpected 503 count %v", v)
+	}
+	if v := testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "/users/:id", "200")); v != 1 {
+		t.Fatalf("unexpected 200 count %v", v)
+	}
+	if v := testutil.ToFloat64(metrics.retries.WithLabelValues("GET", host, "/users/:id")); v != 1 {
+		t.Fatalf("unexpected retries count %v", v)
+	}
+
+	// the collectors of another client are shared
+	if other := NewMetrics(WithRegisterer(registry)); other.requests != metrics.requests {
+		t.Fatal("expected the registered collectors to be reused")
+	}
+}
+
+func TestBreakerCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	breakers := easyhttpbreaker.NewRegistry()
+	breakers.Breaker("users")
+	NewBreakerCollector(breakers, WithRegisterer(registry))
+	expected := `
+# HELP easyhttp_client_breaker_state State of the circuit breakers, 1 for the current state.
+# TYPE easyhttp_client_breaker_state gauge
+easyhttp_client_breaker_state{key="users",state="closed"} 1
+easyhttp_client_breaker_state{key="users",state="half-open"} 0
+easyhttp_client_breaker_state{key="users",state="open"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package easyhttpprometheus
+
+import (
+	"net/http"
+
+	"github.com/soyacen/easyhttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RouteFunc returns the route template of a request, e.g. /users/:id, "" if unknown.
+type RouteFunc func(rawRequest *http.Request) string
+
+type options struct {
+	registerer prometheus.Registerer
+	namespace  string
+	subsystem  string
+	buckets    []float64
+	routeFunc  RouteFunc
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		registerer: prometheus.DefaultRegisterer,
+		namespace:  "easyhttp",
+		subsystem:  "client",
+		buckets:    prometheus.DefBuckets,
+		routeFunc:  easyhttp.RouteOf,
+	}
+}
+
+type Option func(o *options)
+
+// WithRegisterer sets the registerer of the collectors, default is prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = registerer
+	}
+}
+
+// WithNamespace sets the namespace and subsystem of the metric names, default is easyhttp and client.
+func WithNamespace(namespace, subsystem string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+		o.subsystem = subsystem
+	}
+}
+
+// WithBuckets sets the buckets of the request duration histogram, in seconds, default is prometheus.DefBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithRouteFunc sets the function returning the route template used as the route label,
+// default is easyhttp.RouteOf.
+// The raw url is never used as a label, to bound the cardinality of the metrics.
+func WithRouteFunc(routeFunc RouteFunc) Option {
+	return func(o *options) {
+		o.routeFunc = routeFunc
+	}
+}
@@ -0,0 +1,146 @@
+package easyhttpratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// ErrLimited is matched by errors.Is for a *LimitError.
+var ErrLimited = errors.New("rate limited")
+
+// LimitError is returned in fail-fast mode when the rate limit of Key is reached.
+type LimitError struct {
+	Key string
+	// RetryIn is the time to wait before a permit may be available
+	RetryIn time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("easyhttpratelimit: rate limited %q, retry in %s", e.Key, e.RetryIn)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// Interceptor limits the rate of the requests, per key.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	l := &limiters{o: o, keys: make(map[string]*keyLimiter)}
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		key := o.keyFunc(req.RawRequest())
+		limiter := l.get(key, time.Now())
+		if err := limiter.acquire(req, key, o.failFast); err != nil {
+			return nil, err
+		}
+		reply, err := do(cli, req)
+		if o.adaptive {
+			limiter.adapt(reply, err, o)
+		}
+		return reply, err
+	}
+}
+
+type limiters struct {
+	o      *options
+	mu     sync.Mutex
+	keys   map[string]*keyLimiter
+	pruned time.Time
+}
+
+type keyLimiter struct {
+	limiter Limiter
+	// lastUsed is guarded by limiters.mu
+	lastUsed time.Time
+
+	mu     sync.Mutex
+	factor float64
+}
+
+func (l *limiters) get(key string, now time.Time) *keyLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	limiter, ok := l.keys[key]
+	if !ok {
+		limiter = &keyLimiter{limiter: l.o.newLimiter(), factor: 1}
+		l.keys[key] = limiter
+	}
+	limiter.lastUsed = now
+	return limiter
+}
+
+// prune drops the limiters idle for the idle timeout, at most once per idle timeout.
+func (l *limiters) prune(now time.Time) {
+	if l.o.idleTimeout <= 0 || now.Sub(l.pruned) < l.o.idleTimeout {
+		return
+	}
+	l.pruned = now
+	for key, limiter := range l.keys {
+		if now.Sub(limiter.lastUsed) >= l.o.idleTimeout {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// acquire waits for a permit, honoring the context of the request, or fails fast.
+func (l *keyLimiter) acquire(req *easyhttp.Request, key string, failFast bool) error {
+	ctx := req.Context()
+	for {
+		ok, retryIn := l.limiter.TryAcquire(time.Now())
+		if ok {
+			return nil
+		}
+		if failFast {
+			return &LimitError{Key: key, RetryIn: retryIn}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retryIn {
+			return &LimitError{Key: key, RetryIn: retryIn}
+		}
+		timer := time.NewTimer(retryIn)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// adapt shrinks the rate on 429 replies and grows it back on the other replies.
+func (l *keyLimiter) adapt(reply *easyhttp.Reply, err error, o *options) {
+	adaptive, ok := l.limiter.(AdaptiveLimiter)
+	if !ok {
+		return
+	}
+	statusCode := 0
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		statusCode = statusErr.StatusCode
+	} else if reply != nil && reply.RawResponse() != nil {
+		statusCode = reply.RawResponse().StatusCode
+	}
+	if statusCode == 0 {
+		return
+	}
+	l.mu.Lock()
+	factor := l.factor
+	if statusCode == http.StatusTooManyRequests {
+		factor = math.Max(o.minFactor, factor*o.decrease)
+	} else {
+		factor = math.Min(1, factor+o.increase)
+	}
+	changed := factor != l.factor
+	l.factor = factor
+	l.mu.Unlock()
+	if changed {
+		adaptive.SetFactor(factor)
+	}
+}
@@ -0,0 +1,144 @@
+package easyhttpratelimit
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := NewTokenBucket(10, 2)
+	for i := 0; i < 2; i++ {
+		if ok, _ := bucket.TryAcquire(now); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	ok, retryIn := bucket.TryAcquire(now)
+	if ok || retryIn != 100*time.Millisecond {
+		t.Fatalf("unexpected %v %s", ok, retryIn)
+	}
+	if ok, _ := bucket.TryAcquire(now.Add(100 * time.Millisecond)); !ok {
+		t.Fatal("a token should be refilled")
+	}
+}
+
+func TestTokenBucketConcurrent(t *testing.T) {
+	start := time.Now()
+	bucket := NewTokenBucket(10, 1)
+	// one second of requests every 10ms, acquired concurrently so out of order
+	nows := make(chan time.Time, 100)
+	for _, i := range rand.Perm(cap(nows)) {
+		nows <- start.Add(time.Duration(i) * 10 * time.Millisecond)
+	}
+	close(nows)
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for now := range nows {
+				if ok, _ := bucket.TryAcquire(now); ok {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// the burst plus 10 tokens refilled in 990ms
+	if allowed > 10 {
+		t.Fatalf("the rate is exceeded, %d requests allowed", allowed)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	window := NewSlidingWindow(4, time.Second)
+	for i := 0; i < 4; i++ {
+		if ok, _ := window.TryAcquire(start); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if ok, retryIn := window.TryAcquire(start.Add(500 * time.Millisecond)); ok || retryIn != 500*time.Millisecond {
+		t.Fatalf("unexpected %v %s", ok, retryIn)
+	}
+	// a quarter into the next window, the previous window weighs 3 requests
+	if ok, _ := window.TryAcquire(start.Add(1250 * time.Millisecond)); !ok {
+		t.Fatal("request should be allowed")
+	}
+	if ok, retryIn := window.TryAcquire(start.Add(1250 * time.Millisecond)); ok || retryIn != 250*time.Millisecond {
+		t.Fatalf("unexpected %v %s", ok, retryIn)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(WithTokenBucket(20, 1))))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("requests were not throttled, took %s", elapsed)
+	}
+
+	client = easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(WithTokenBucket(1, 1), WithFailFast())))
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Get(context.Background(), server.URL)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimited) {
+		t.Fatalf("expected a LimitError, got %v", err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	o := defaultOptions()
+	o.apply(WithIdleTimeout(time.Minute))
+	l := &limiters{o: o, keys: make(map[string]*keyLimiter)}
+	now := time.Now()
+	l.get("a", now)
+	l.get("b", now.Add(30*time.Second))
+	l.get("b", now.Add(time.Minute))
+	if _, ok := l.keys["a"]; ok {
+		t.Fatal("expected the idle key to be dropped")
+	}
+	if _, ok := l.keys["b"]; !ok {
+		t.Fatal("expected the used key to be kept")
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	bucket := NewTokenBucket(100, 10)
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(
+		WithLimiter(func() Limiter { return bucket }),
+		WithAdaptive(0.5, 0.1, 0.1),
+	)))
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bucket.factor != 0.125 {
+		t.Fatalf("unexpected factor %v", bucket.factor)
+	}
+}
@@ -0,0 +1,126 @@
+package easyhttpratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of the requests of a key.
+type Limiter interface {
+	// TryAcquire takes a permit if one is available at now,
+	// otherwise it returns the time to wait before trying again.
+	TryAcquire(now time.Time) (ok bool, retryIn time.Duration)
+}
+
+// AdaptiveLimiter is a Limiter whose rate can be scaled, as required by the adaptive mode.
+type AdaptiveLimiter interface {
+	Limiter
+	// SetFactor scales the configured rate by factor (0-1].
+	SetFactor(factor float64)
+}
+
+// TokenBucket is a token bucket limiter: tokens are added at rate per second up to burst,
+// each request takes a token.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	factor float64
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: float64(burst), factor: 1, tokens: float64(burst)}
+}
+
+func (b *TokenBucket) TryAcquire(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rate := b.rate * b.factor
+	burst := math.Max(1, b.burst*b.factor)
+	// concurrent callers may pass their now out of order, last never moves back
+	// or the time between an earlier now and last would be refilled twice
+	if b.last.IsZero() {
+		b.last = now
+	} else if now.After(b.last) {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if rate <= 0 {
+		return false, time.Second
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+func (b *TokenBucket) SetFactor(factor float64) {
+	b.mu.Lock()
+	b.factor = factor
+	b.mu.Unlock()
+}
+
+// SlidingWindow allows limit requests in any window, it weights the count of the previous fixed window
+// by its overlap with the sliding window.
+type SlidingWindow struct {
+	limit  float64
+	window time.Duration
+
+	mu       sync.Mutex
+	factor   float64
+	start    time.Time
+	previous float64
+	current  float64
+}
+
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{limit: float64(limit), window: window, factor: 1}
+}
+
+func (w *SlidingWindow) TryAcquire(now time.Time) (bool, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.start.IsZero() {
+		w.start = now.Truncate(w.window)
+	}
+	if elapsed := now.Sub(w.start); elapsed >= w.window {
+		if elapsed < 2*w.window {
+			w.previous = w.current
+		} else {
+			w.previous = 0
+		}
+		w.current = 0
+		w.start = now.Truncate(w.window)
+	}
+	limit := math.Max(1, math.Floor(w.limit*w.factor))
+	elapsed := now.Sub(w.start)
+	weight := 1 - float64(elapsed)/float64(w.window)
+	if w.previous*weight+w.current+1 <= limit {
+		w.current++
+		return true, 0
+	}
+	end := w.start.Add(w.window).Sub(now)
+	if w.current+1 > limit || w.previous <= 0 {
+		return false, end
+	}
+	// time until the weight of the previous window leaves room for one more request
+	free := time.Duration((1 - (limit-w.current-1)/w.previous) * float64(w.window))
+	if wait := free - elapsed; wait > 0 && wait < end {
+		return false, wait
+	}
+	return false, end
+}
+
+func (w *SlidingWindow) SetFactor(factor float64) {
+	w.mu.Lock()
+	w.factor = factor
+	w.mu.Unlock()
+}
@@ -0,0 +1,112 @@
+package easyhttpratelimit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// KeyFunc returns the key of a request, each key has its own limiter.
+type KeyFunc func(rawRequest *http.Request) string
+
+// ByHost limits each host separately.
+func ByHost(rawRequest *http.Request) string {
+	return rawRequest.URL.Host
+}
+
+// ByRoute limits each method, host and route separately, see easyhttp.RouteKey.
+func ByRoute(rawRequest *http.Request) string {
+	return easyhttp.RouteKey(rawRequest)
+}
+
+// Global shares a single limiter by every request.
+func Global(rawRequest *http.Request) string {
+	return ""
+}
+
+type options struct {
+	newLimiter  func() Limiter
+	keyFunc     KeyFunc
+	failFast    bool
+	idleTimeout time.Duration
+
+	adaptive  bool
+	decrease  float64
+	increase  float64
+	minFactor float64
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		newLimiter:  func() Limiter { return NewTokenBucket(10, 10) },
+		keyFunc:     ByHost,
+		idleTimeout: 10 * time.Minute,
+	}
+}
+
+type Option func(o *options)
+
+// WithTokenBucket limits the requests of each key to rate per second, with bursts of burst requests.
+// It is the default, with 10 requests per second.
+func WithTokenBucket(rate float64, burst int) Option {
+	return func(o *options) {
+		o.newLimiter = func() Limiter { return NewTokenBucket(rate, burst) }
+	}
+}
+
+// WithSlidingWindow limits the requests of each key to limit in any window.
+func WithSlidingWindow(limit int, window time.Duration) Option {
+	return func(o *options) {
+		o.newLimiter = func() Limiter { return NewSlidingWindow(limit, window) }
+	}
+}
+
+// WithLimiter sets the factory of the limiter of each key.
+func WithLimiter(newLimiter func() Limiter) Option {
+	return func(o *options) {
+		o.newLimiter = newLimiter
+	}
+}
+
+// WithKey sets the function returning the key of a request, default is ByHost.
+func WithKey(keyFunc KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = keyFunc
+	}
+}
+
+// WithIdleTimeout drops the limiter of a key unused for timeout, default is 10 minutes,
+// 0 keeps every limiter. A dropped key starts again with a fresh limiter, so keep the
+// timeout longer than the sliding window and the refill time of the token bucket.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithFailFast returns a *LimitError instead of waiting for a permit.
+// By default, the request waits until a permit is available or its context is done.
+func WithFailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// WithAdaptive shrinks the rate of a key by multiplying it by decrease (e.g. 0.5) on each 429 reply,
+// down to minFactor of the configured rate, and grows it back by increase (e.g. 0.05) on each other reply.
+// The limiters must implement AdaptiveLimiter.
+func WithAdaptive(decrease, increase, minFactor float64) Option {
+	return func(o *options) {
+		o.adaptive = true
+		o.decrease = decrease
+		o.increase = increase
+		o.minFactor = minFactor
+	}
+}
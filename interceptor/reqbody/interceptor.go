@@ -1,25 +1,17 @@
 package easyhttpreqbody
 
 import (
-	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"io"
-	"io/ioutil"
-	"net/http"
 	"net/url"
-	"strconv"
 
 	"github.com/soyacen/bytebufferpool"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/soyacen/easyhttp"
-)
-
-var (
-	kContentTypeKey   = http.CanonicalHeaderKey("Content-Type")
-	kContentLengthKey = http.CanonicalHeaderKey("Content-Length")
+	"github.com/soyacen/easyhttp/internal/bodyutils"
 )
 
 const (
@@ -31,34 +23,7 @@ const (
 )
 
 func setContent(bodyBuf *bytebufferpool.ByteBuffer, req *easyhttp.Request, ct string) {
-	rawRequest := req.RawRequest()
-	rawRequest.Body = ioutil.NopCloser(bytes.NewReader(bodyBuf.Bytes()))
-	rawRequest.ContentLength = int64(bodyBuf.Len())
-	buf := bodyBuf.Bytes()
-	rawRequest.GetBody = func() (io.ReadCloser, error) {
-		return ioutil.NopCloser(bytes.NewReader(buf)), nil
-	}
-	if rawRequest.Header == nil {
-		rawRequest.Header = make(http.Header)
-	}
-	rawRequest.Header.Set(kContentTypeKey, ct)
-	rawRequest.Header.Set(kContentLengthKey, strconv.Itoa(bodyBuf.Len()))
-}
-
-func writeObj(obj interface{}, bodyBuf *bytebufferpool.ByteBuffer, marshal func(v interface{}) ([]byte, error)) error {
-	switch obj.(type) {
-	case string:
-		bodyBuf.WriteString(obj.(string))
-	case []byte:
-		bodyBuf.Write(obj.([]byte))
-	default:
-		data, err := marshal(obj)
-		if err != nil {
-			return err
-		}
-		bodyBuf.Write(data)
-	}
-	return nil
+	bodyutils.SetBytes(req.RawRequest(), bodyBuf.Bytes(), ct)
 }
 
 func Reader(body io.Reader, contentType string) easyhttp.Interceptor {
@@ -124,11 +89,13 @@ func Protobuf(obj proto.Message, marshalFunc ...func(v proto.Message) ([]byte, e
 
 func Object(obj interface{}, contentType string, marshalFunc func(v interface{}) ([]byte, error)) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		bodyBuf := bytebufferpool.Get()
-		defer bodyBuf.Free()
-		if err := writeObj(obj, bodyBuf, marshalFunc); err != nil {
+		data, err := bodyutils.Marshal(obj, marshalFunc)
+		if err != nil {
 			return nil, err
 		}
+		bodyBuf := bytebufferpool.Get()
+		defer bodyBuf.Free()
+		bodyBuf.Write(data)
 		setContent(bodyBuf, req, contentType)
 		return do(cli, req)
 	}
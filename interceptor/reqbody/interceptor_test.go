@@ -15,7 +15,7 @@ func TestJSONString(t *testing.T) {
 	reply, err := client.Post(
 		context.Background(),
 		"http://httpbin.org/post",
-		easyhttp.ChainInterceptor(JSON(`{"extra":null,"accesskey":"rfe65iisgjr4ltgp","expid":"62","entity":"869791045881921","traceid":"f7eef0d861379d6681940f07b548eff1","bucket":"2499","group":"174","ts":"1620389233"}`)))
+		JSON(`{"extra":null,"accesskey":"rfe65iisgjr4ltgp","expid":"62","entity":"869791045881921","traceid":"f7eef0d861379d6681940f07b548eff1","bucket":"2499","group":"174","ts":"1620389233"}`))
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -49,7 +49,7 @@ func TestExampleXML(t *testing.T) {
 	reply, err := client.Post(
 		context.Background(),
 		"http://httpbin.org/post",
-		easyhttp.ChainInterceptor(XML(data)))
+		XML(data))
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -75,7 +75,7 @@ func TestJSON(t *testing.T) {
 	reply, err := client.Post(
 		context.Background(),
 		"http://httpbin.org/post",
-		easyhttp.ChainInterceptor(JSON(data)))
+		JSON(data))
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -101,7 +101,7 @@ func TestForm(t *testing.T) {
 	reply, err := client.Post(
 		context.Background(),
 		"http://httpbin.org/post",
-		easyhttp.ChainInterceptor(Form(form)))
+		Form(form))
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -0,0 +1,93 @@
+package easyhttpreqbody
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+
+	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/bodyutils"
+)
+
+const kOctetStreamContentType = "application/octet-stream"
+
+func streamContentType(contentType []string) string {
+	if len(contentType) > 0 {
+		return contentType[0]
+	}
+	return kOctetStreamContentType
+}
+
+// ErrStreamSent is returned when a body given by Stream has to be sent again, e.g. by a retry interceptor
+// running before Stream: the body was read by the previous attempt and can not be rewound.
+var ErrStreamSent = errors.New("easyhttpreqbody: the stream body was already sent, it can not be sent again")
+
+// Stream sends body as it is read, without buffering it in memory.
+// size is the length of body, a negative size means unknown and the body is sent chunked.
+// The default content type is application/octet-stream.
+//
+// A body given by Stream is sent once, it can not be rewound. An interceptor running before Stream,
+// e.g. a client retry interceptor, can not buffer it either since the body is not set yet: a second attempt
+// fails with ErrStreamSent instead of sending an empty body.
+// Use StreamFunc or File for large bodies of requests that may be retried or redirected.
+func Stream(body io.Reader, size int64, contentType ...string) easyhttp.Interceptor {
+	ct := streamContentType(contentType)
+	rc, ok := body.(io.ReadCloser)
+	if !ok {
+		rc = ioutil.NopCloser(body)
+	}
+	once := &onceBody{ReadCloser: rc}
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		if once.sent() {
+			return nil, ErrStreamSent
+		}
+		bodyutils.SetStream(req.RawRequest(), once, nil, size, ct)
+		return do(cli, req)
+	}
+}
+
+// onceBody records whether the body was read.
+type onceBody struct {
+	io.ReadCloser
+	read int32
+}
+
+func (b *onceBody) Read(p []byte) (int, error) {
+	atomic.StoreInt32(&b.read, 1)
+	return b.ReadCloser.Read(p)
+}
+
+func (b *onceBody) sent() bool {
+	return atomic.LoadInt32(&b.read) != 0
+}
+
+// StreamFunc sends the body returned by open without buffering it in memory.
+// open is called when the body is first read, so it is not called for a request that is never sent,
+// and again each time the body has to be rewound, e.g. by the retry interceptor or on redirects.
+// size is the length of body, a negative size means unknown and the body is sent chunked.
+func StreamFunc(open func() (io.ReadCloser, error), size int64, contentType ...string) easyhttp.Interceptor {
+	ct := streamContentType(contentType)
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		bodyutils.SetStream(req.RawRequest(), bodyutils.LazyBody(open), open, size, ct)
+		return do(cli, req)
+	}
+}
+
+// File streams the file at path as the body, the file is opened when the body is first read
+// and reopened to rewind the body.
+func File(path string, contentType ...string) easyhttp.Interceptor {
+	ct := streamContentType(contentType)
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		open := func() (io.ReadCloser, error) {
+			return os.Open(path)
+		}
+		bodyutils.SetStream(req.RawRequest(), bodyutils.LazyBody(open), open, info.Size(), ct)
+		return do(cli, req)
+	}
+}
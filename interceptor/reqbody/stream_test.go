@@ -0,0 +1,97 @@
+package easyhttpreqbody
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpretry "github.com/soyacen/easyhttp/interceptor/retry"
+)
+
+func TestFileRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "easyhttp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "upload.txt")
+	if err := ioutil.WriteFile(path, []byte("streaming body"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(easyhttpretry.Interceptor(
+		easyhttpretry.WithMaxAttempts(1),
+		easyhttpretry.WithIdempotencyKey(nil),
+	)))
+	reply, err := client.Post(context.Background(), server.URL, File(path, "text/plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.RawResponse().StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", reply.RawResponse().StatusCode)
+	}
+	if len(bodies) != 2 || bodies[0] != "streaming body" || bodies[1] != "streaming body" {
+		t.Fatalf("unexpected bodies %q", bodies)
+	}
+}
+
+func TestStreamRetry(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(easyhttpretry.Interceptor(
+		easyhttpretry.WithMaxAttempts(1),
+		easyhttpretry.WithIdempotencyKey(nil),
+	)))
+	_, err := client.Post(context.Background(), server.URL, Stream(strings.NewReader("payload"), -1))
+	if !errors.Is(err, ErrStreamSent) {
+		t.Fatalf("expected ErrStreamSent, got %v", err)
+	}
+	if len(bodies) != 1 || bodies[0] != "payload" {
+		t.Fatalf("unexpected bodies %q", bodies)
+	}
+}
+
+func TestStreamFuncShortCircuit(t *testing.T) {
+	opened := 0
+	open := func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader("never sent")), nil
+	}
+	shortCircuit := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		req.RawRequest().Body.Close()
+		return nil, errors.New("short circuit")
+	}
+	client := easyhttp.NewClient()
+	if _, err := client.Post(context.Background(), "http://127.0.0.1:1", StreamFunc(open, -1), shortCircuit); err == nil {
+		t.Fatal("expected the short circuit error")
+	}
+	if opened != 0 {
+		t.Fatalf("body opened %d times for a request never sent", opened)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 
@@ -103,13 +102,7 @@ func XML(obj interface{}, opts ...Option) easyhttp.Interceptor {
 func Protobuf(obj proto.Message, opts ...Option) easyhttp.Interceptor {
 	o := defaultOptions()
 	o.contentType = kProtobufContentType
-	o.unmarshalFunc = func(data []byte, v interface{}) error {
-		message, ok := v.(proto.Message)
-		if !ok {
-			return errors.New("failed convert obj to proto.Message")
-		}
-		return proto.Unmarshal(data, message)
-	}
+	o.unmarshalFunc = easyhttp.UnmarshalProto
 	o.apply(opts...)
 	return object(obj, o)
 }
@@ -136,30 +129,23 @@ func object(obj interface{}, o *options) easyhttp.Interceptor {
 		if err != nil {
 			return reply, err
 		}
-		rawResponse := reply.RawResponse()
-		if reply == nil || rawResponse == nil {
+		if reply == nil || reply.RawResponse() == nil {
 			return reply, err
 		}
-		body := rawResponse.Body
-		if body != nil {
-			defer ioutils.CloseThrowError(body, &err)
-		}
+		rawResponse := reply.RawResponse()
 		if rawResponse.ContentLength == 0 {
+			if rawResponse.Body != nil {
+				defer ioutils.CloseThrowError(rawResponse.Body, &err)
+			}
 			return reply, err
 		}
 		ct := rawResponse.Header.Get(kContentTypeKey)
 		if o.checkContentType && stringutils.IsNotBlank(ct) && !strings.Contains(ct, o.contentType) {
+			if rawResponse.Body != nil {
+				defer ioutils.CloseThrowError(rawResponse.Body, &err)
+			}
 			return reply, fmt.Errorf("expexted content-type is %s, but actual content-type is %s", o.contentType, ct)
 		}
-
-		data, err := ioutil.ReadAll(body)
-		if err != nil {
-			return reply, err
-		}
-		err = o.unmarshalFunc(data, obj)
-		if err != nil {
-			return reply, err
-		}
-		return reply, err
+		return reply, reply.Decode(obj, o.unmarshalFunc)
 	}
 }
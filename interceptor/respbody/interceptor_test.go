@@ -14,7 +14,7 @@ func TestRespBody(t *testing.T) {
 	reply, err := client.Get(
 		context.Background(),
 		"http://httpbin.org/json",
-		easyhttp.ChainInterceptor(JSON(&data)))
+		JSON(&data))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,7 +26,7 @@ func TestRespBody(t *testing.T) {
 	reply1, err := client.Get(
 		context.Background(),
 		"http://httpbin.org/gzip",
-		easyhttp.ChainInterceptor(JSON(&data1)))
+		JSON(&data1))
 	if err != nil {
 		log.Fatalln(err)
 	}
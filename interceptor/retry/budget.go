@@ -0,0 +1,79 @@
+package easyhttpretry
+
+import (
+	"sync"
+	"time"
+)
+
+const kBudgetBuckets = 10
+
+// Budget is a retry budget, it caps the retries to a ratio of the requests,
+// so retries can not multiply the load on a failing server.
+// A Budget is shared by every call of the interceptors it is given to.
+//
+// Every request deposits ratio tokens and every retry withdraws one,
+// deposits and withdrawals expire after ttl. A reserve of minRetriesPerSecond
+// allows retries when there are few requests.
+type Budget struct {
+	ratio   float64
+	reserve float64
+	width   time.Duration
+
+	mu      sync.Mutex
+	buckets [kBudgetBuckets]budgetBucket
+}
+
+type budgetBucket struct {
+	index       int64
+	deposits    int
+	withdrawals int
+}
+
+// NewBudget creates a Budget, e.g. NewBudget(0.2, 10, 10*time.Second) allows 20% of retries,
+// plus 10 retries per second. ttl <= 0 means 10 seconds.
+func NewBudget(ratio float64, minRetriesPerSecond int, ttl time.Duration) *Budget {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+	return &Budget{
+		ratio:   ratio,
+		reserve: float64(minRetriesPerSecond) * ttl.Seconds(),
+		width:   ttl / kBudgetBuckets,
+	}
+}
+
+// Deposit records a request.
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	b.bucket(time.Now()).deposits++
+	b.mu.Unlock()
+}
+
+// TryWithdraw records a retry and reports whether the budget allows it.
+func (b *Budget) TryWithdraw() bool {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	current := b.bucket(now)
+	deposits, withdrawals := 0, 0
+	for i := range b.buckets {
+		if b.buckets[i].index > current.index-kBudgetBuckets {
+			deposits += b.buckets[i].deposits
+			withdrawals += b.buckets[i].withdrawals
+		}
+	}
+	if float64(deposits)*b.ratio+b.reserve-float64(withdrawals) < 1 {
+		return false
+	}
+	current.withdrawals++
+	return true
+}
+
+func (b *Budget) bucket(now time.Time) *budgetBucket {
+	index := now.UnixNano() / int64(b.width)
+	bucket := &b.buckets[index%kBudgetBuckets]
+	if bucket.index != index {
+		*bucket = budgetBucket{index: index}
+	}
+	return bucket
+}
@@ -0,0 +1,35 @@
+package easyhttpretry
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key of a request.
+const IdempotencyKeyHeader = easyhttp.IdempotencyKeyHeader
+
+// RetryWithRequest whether the request can be retried at all.
+type RetryWithRequest func(rawRequest *http.Request) bool
+
+// IsIdempotent reports whether the request can be sent several times without side effects,
+// see easyhttp.IsIdempotent.
+func IsIdempotent(rawRequest *http.Request) bool {
+	return easyhttp.IsIdempotent(rawRequest)
+}
+
+// AnyRequest allows to retry any request, whatever its method.
+func AnyRequest(rawRequest *http.Request) bool {
+	return true
+}
+
+// NewIdempotencyKey returns a random UUID (version 4).
+func NewIdempotencyKey() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
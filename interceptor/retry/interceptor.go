@@ -1,17 +1,16 @@
 package easyhttpretry
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/soyacen/bytebufferpool"
-
 	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/bodyutils"
 )
 
 func Interceptor(opts ...Option) easyhttp.Interceptor {
@@ -25,82 +24,120 @@ func Interceptor(opts ...Option) easyhttp.Interceptor {
 		rawRequest := req.RawRequest()
 		rawCtx := rawRequest.Context()
 
-		var bodyReader *bytes.Reader
-		bodyBuf := bytebufferpool.Get()
-		defer bodyBuf.Free()
-		if rawRequest.Body != nil {
-			_, err := bodyBuf.ReadFrom(rawRequest.Body)
-			if err != nil {
-				return nil, err
+		if o.idempotencyKey != nil && !IsIdempotent(rawRequest) {
+			if rawRequest.Header == nil {
+				rawRequest.Header = make(http.Header)
+			}
+			rawRequest.Header.Set(IdempotencyKeyHeader, o.idempotencyKey())
+		}
+		// a request that can not be sent twice is sent once,
+		// still with the timeout and the attempt number of the attempts
+		maxAttempts := o.maxAttempts
+		if !o.shouldRetryWithRequest(rawRequest) {
+			maxAttempts = 0
+		} else {
+			if o.budget != nil {
+				o.budget.Deposit()
 			}
-			bodyReader = bytes.NewReader(bodyBuf.Bytes())
-			rawRequest.Body = ioutil.NopCloser(bodyReader)
-			rawRequest.ContentLength = int64(bodyBuf.Len())
-			rawRequest.GetBody = func() (io.ReadCloser, error) {
-				return ioutil.NopCloser(bytes.NewBuffer(bodyBuf.Bytes())), nil
+			// a body that can not be rewound by GetBody is buffered, so it can be replayed.
+			// Streaming bodies should come with a GetBody factory, see easyhttpreqbody.StreamFunc.
+			if err := bodyutils.MakeRewindable(rawRequest); err != nil {
+				return nil, err
 			}
 		}
 
-		for attempt := uint(0); attempt <= o.maxAttempts; attempt++ {
-			newRequest := spawnRequest(rawRequest, o.timeout, attempt)
-			req.SetRawRequest(newRequest)
-
-			if bodyReader != nil {
-				// Reset the body reader after the request since at this point it's already read
-				// Note that it's safe to ignore the error here since the 0,0 position is always valid
-				_, _ = bodyReader.Seek(0, 0)
+		for attempt := uint(0); attempt <= maxAttempts; attempt++ {
+			if attempt > 0 && rawRequest.GetBody != nil {
+				// rewind the body, since the previous attempt has already read it
+				body, e := rawRequest.GetBody()
+				if e != nil {
+					return reply, e
+				}
+				rawRequest.Body = body
 			}
+			newRequest, cancel := spawnRequest(rawRequest, o, attempt)
+			req.SetRawRequest(newRequest)
 
 			// call do
 			reply, err = do(cli, req)
 
-			// if have already retried the maximum number, return result
-			if attempt == o.maxAttempts {
-				return reply, err
+			// if have already retried the maximum number, or raw context is deadline or canceled,
+			// or the result should not retry, return result
+			waitReply, retry := o.shouldRetry(reply, err)
+			if !retry || attempt == maxAttempts || rawCtx.Err() != nil {
+				return reply, releaseOnClose(reply, err, cancel)
 			}
 
-			// if raw context is deadline or canceled, just return result
-			if rawCtx.Err() != nil {
-				return reply, err
+			wait, e := decideWait(attempt, rawCtx, o, waitReply, err)
+			if e != nil {
+				return reply, releaseOnClose(reply, err, cancel)
 			}
-
-			// check error retry condition
-			if err != nil {
-				// if this error should not retry, return result
-				if !o.shouldRetryWithError(err) {
-					return reply, err
-				}
-				// wait time
-				if e := waitRetryBackoff(attempt, rawCtx, o); e != nil {
-					return reply, err
-				}
-				continue
+			for _, onRetry := range o.onRetry {
+				onRetry(rawCtx, attempt+1, reply, err, wait)
 			}
-
-			// reply or raw response is nil, must be intercept, just return
-			if reply == nil || reply.RawResponse() == nil {
-				return reply, err
+			if e := sleep(rawCtx, wait); e != nil {
+				return reply, releaseOnClose(reply, err, cancel)
 			}
-
-			// check code retry condition
-			if reply != nil && reply.RawResponse() != nil {
-				// check status code, if code should not retry, return result
-				if !o.shouldRetryWithStatusCode(reply.RawResponse().StatusCode) {
-					return reply, err
-				}
-				// if code is need retry,continue
-				if e := waitRetryBackoff(attempt, rawCtx, o); e != nil {
-					return reply, err
-				}
-				continue
+			// the reply is dropped, release its connection and its context
+			discard(reply)
+			if cancel != nil {
+				cancel()
 			}
 		}
 		return reply, err
 	}
 }
 
-func waitRetryBackoff(attempt uint, parentCtx context.Context, callOpts *options) error {
-	waitTime := callOpts.backoffFunc(parentCtx, attempt)
+// shouldRetry checks the retry conditions, it returns the reply carrying the status code if any.
+func (opt *options) shouldRetry(reply *easyhttp.Reply, err error) (*easyhttp.Reply, bool) {
+	// a StatusError carries a reply, check status code retry condition
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Reply(), opt.shouldRetryWithStatusCode(statusErr.StatusCode)
+	}
+	// check error retry condition
+	if err != nil {
+		return reply, opt.shouldRetryWithError(err)
+	}
+	// reply or raw response is nil, must be intercept, just return
+	if reply == nil || reply.RawResponse() == nil {
+		return reply, false
+	}
+	// check status code retry condition
+	return reply, opt.shouldRetryWithStatusCode(reply.RawResponse().StatusCode)
+}
+
+var errGiveUp = errors.New("easyhttpretry: give up retrying")
+
+// decideWait decides the wait before the next attempt, it returns an error to give up retrying.
+func decideWait(attempt uint, parentCtx context.Context, callOpts *options, reply *easyhttp.Reply, err error) (time.Duration, error) {
+	decision := Decision{Attempt: attempt, Reply: reply, Err: err}
+	decision.Backoff = callOpts.backoffFunc(parentCtx, attempt)
+	decision.Wait = decision.Backoff
+	if callOpts.honorServerWait && reply != nil {
+		if wait, ok := serverWait(reply.RawResponse(), time.Now()); ok {
+			if callOpts.maxServerWait > 0 && wait > callOpts.maxServerWait {
+				wait = callOpts.maxServerWait
+			}
+			decision.ServerWait, decision.HasServerWait = wait, true
+			decision.Wait = wait
+		}
+	}
+	waitTime := decision.Wait
+	if callOpts.decide != nil {
+		var retry bool
+		waitTime, retry = callOpts.decide(decision)
+		if !retry {
+			return 0, errGiveUp
+		}
+	}
+	if callOpts.budget != nil && !callOpts.budget.TryWithdraw() {
+		return 0, errGiveUp
+	}
+	return waitTime, nil
+}
+
+func sleep(parentCtx context.Context, waitTime time.Duration) error {
 	if waitTime > 0 {
 		timer := time.NewTimer(waitTime)
 		select {
@@ -113,16 +150,58 @@ func waitRetryBackoff(attempt uint, parentCtx context.Context, callOpts *options
 	return nil
 }
 
-func spawnRequest(rawRequest *http.Request, timeout time.Duration, attempt uint) *http.Request {
-	ctx := rawRequest.Context()
-	if timeout != 0 {
-		ctx, _ = context.WithTimeout(ctx, timeout)
+// spawnRequest creates the request of an attempt, its context carries the attempt number
+// and the timeout of the attempt, the returned cancel func is nil if there is no timeout.
+func spawnRequest(rawRequest *http.Request, o *options, attempt uint) (*http.Request, context.CancelFunc) {
+	ctx := easyhttp.ContextWithAttempt(rawRequest.Context(), attempt)
+	var cancel context.CancelFunc
+	if o.timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
 	}
-	if attempt > 0 {
+	if attempt > 0 && o.attemptHeader != "" {
 		if rawRequest.Header == nil {
 			rawRequest.Header = make(http.Header)
 		}
-		rawRequest.Header.Set("X-Retry", strconv.Itoa(int(attempt)))
+		rawRequest.Header.Set(o.attemptHeader, strconv.Itoa(int(attempt)))
 	}
-	return rawRequest.WithContext(ctx)
+	return rawRequest.WithContext(ctx), cancel
+}
+
+// releaseOnClose cancels the context of the returned attempt once its response body is closed,
+// so the body can still be read after the interceptor returns.
+func releaseOnClose(reply *easyhttp.Reply, err error, cancel context.CancelFunc) error {
+	if cancel == nil {
+		return err
+	}
+	if reply == nil || reply.RawResponse() == nil || reply.RawResponse().Body == nil {
+		cancel()
+		return err
+	}
+	response := reply.RawResponse()
+	response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+	return err
+}
+
+// discard drains and closes the response body of a dropped reply, so its connection can be reused.
+func discard(reply *easyhttp.Reply) {
+	if reply == nil || reply.RawResponse() == nil || reply.RawResponse().Body == nil {
+		return
+	}
+	body := reply.RawResponse().Body
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, kMaxDrainSize))
+	_ = body.Close()
+}
+
+// kMaxDrainSize is the maximum size read from a dropped response body.
+const kMaxDrainSize = 64 << 10
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
@@ -0,0 +1,241 @@
+package easyhttpretry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestServerWait(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		status int
+		header map[string]string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"seconds", http.StatusServiceUnavailable, map[string]string{"Retry-After": "3"}, 3 * time.Second, true},
+		{"date", http.StatusTooManyRequests, map[string]string{"Retry-After": now.Add(5 * time.Second).Format(http.TimeFormat)}, 5 * time.Second, true},
+		{"past date", http.StatusTooManyRequests, map[string]string{"Retry-After": now.Add(-time.Second).Format(http.TimeFormat)}, 0, true},
+		{"reset delta", http.StatusTooManyRequests, map[string]string{"X-RateLimit-Reset": "2"}, 2 * time.Second, true},
+		{"reset timestamp", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1609459210"}, 10 * time.Second, true},
+		{"reset not exhausted", http.StatusServiceUnavailable, map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": "2"}, 0, false},
+		{"none", http.StatusServiceUnavailable, nil, 0, false},
+	}
+	for _, c := range cases {
+		response := &http.Response{StatusCode: c.status, Header: make(http.Header)}
+		for key, value := range c.header {
+			response.Header.Set(key, value)
+		}
+		wait, ok := serverWait(response, now)
+		if wait != c.wait || ok != c.ok {
+			t.Errorf("%s: expected %v %v, got %v %v", c.name, c.wait, c.ok, wait, ok)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var decisions []Decision
+	client := easyhttp.NewClient()
+	reply, err := client.Get(context.Background(), server.URL, Interceptor(
+		WithMaxAttempts(2),
+		WithServerWait(true, 10*time.Millisecond),
+		WithDecide(func(decision Decision) (time.Duration, bool) {
+			decisions = append(decisions, decision)
+			return decision.Wait, true
+		}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.RawResponse().StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", reply.RawResponse().StatusCode)
+	}
+	if len(decisions) != 1 || !decisions[0].HasServerWait || decisions[0].Wait != 10*time.Millisecond {
+		t.Fatalf("unexpected decisions %+v", decisions)
+	}
+
+	calls = 0
+	_, _ = client.Get(context.Background(), server.URL, Interceptor(
+		WithMaxAttempts(2),
+		WithDecide(func(decision Decision) (time.Duration, bool) {
+			return 0, decision.ServerWait < time.Second
+		}),
+	))
+	if calls != 1 {
+		t.Fatalf("expected the hook to give up, got %d calls", calls)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	_, _ = client.Post(context.Background(), server.URL, Interceptor(WithMaxAttempts(2), WithBackoff(noBackoff)))
+	if len(keys) != 1 {
+		t.Fatalf("expected POST not to be retried, got %d calls", len(keys))
+	}
+
+	keys = nil
+	_, _ = client.Post(context.Background(), server.URL, Interceptor(
+		WithMaxAttempts(2),
+		WithBackoff(noBackoff),
+		WithIdempotencyKey(nil),
+	))
+	if len(keys) != 3 || keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Fatalf("unexpected idempotency keys %q", keys)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(0.5, 0, time.Second)
+	for i := 0; i < 4; i++ {
+		budget.Deposit()
+	}
+	for i := 0; i < 2; i++ {
+		if !budget.TryWithdraw() {
+			t.Fatalf("retry %d should be allowed", i)
+		}
+	}
+	if budget.TryWithdraw() {
+		t.Fatal("budget should be exhausted")
+	}
+}
+
+func noBackoff(ctx context.Context, attempt uint) time.Duration {
+	return 0
+}
+
+func TestOnRetry(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Attempt"))
+		if len(headers) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	var hooked []uint
+	var attempts []uint
+	client := easyhttp.NewClient()
+	reply, err := client.Get(context.Background(), server.URL,
+		Interceptor(
+			WithMaxAttempts(3),
+			WithBackoff(noBackoff),
+			WithTimeout(time.Second),
+			WithAttemptHeader("X-Attempt"),
+			WithOnRetry(func(ctx context.Context, attempt uint, reply *easyhttp.Reply, err error, wait time.Duration) {
+				hooked = append(hooked, attempt)
+			}),
+		),
+		func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+			attempt, _ := easyhttp.AttemptFromContext(req.Context())
+			attempts = append(attempts, attempt)
+			return do(cli, req)
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the context of the last attempt is alive until its body is closed
+	body, err := reply.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "body" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if len(hooked) != 2 || hooked[0] != 1 || hooked[1] != 2 {
+		t.Fatalf("unexpected hooked attempts %v", hooked)
+	}
+	if len(attempts) != 3 || attempts[0] != 0 || attempts[2] != 2 {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+	if len(headers) != 3 || headers[0] != "" || headers[1] != "1" || headers[2] != "2" {
+		t.Fatalf("unexpected attempt headers %q", headers)
+	}
+}
+
+func TestRetryStatusError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("recovered"))
+	}))
+	defer server.Close()
+
+	// the status is checked inside the per-call retry interceptor
+	client := easyhttp.NewClient(easyhttp.WithErrorOnStatus(nil))
+	var retryErrs []error
+	reply, err := client.Get(context.Background(), server.URL, Interceptor(
+		WithMaxAttempts(3),
+		WithOnRetry(func(ctx context.Context, attempt uint, reply *easyhttp.Reply, err error, wait time.Duration) {
+			retryErrs = append(retryErrs, err)
+		}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := reply.Text(); body != "recovered" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("unexpected body %q after %d calls", body, calls)
+	}
+	for _, retryErr := range retryErrs {
+		var statusErr *easyhttp.StatusError
+		if !errors.As(retryErr, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected a StatusError, got %v", retryErr)
+		}
+	}
+	if len(retryErrs) != 2 {
+		t.Fatalf("expected 2 retries, got %d", len(retryErrs))
+	}
+}
+
+func TestNotRetriedAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var deadline, attempt bool
+	client := easyhttp.NewClient()
+	_, err := client.Post(context.Background(), server.URL,
+		Interceptor(WithMaxAttempts(2), WithTimeout(time.Minute)),
+		func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+			_, deadline = req.Context().Deadline()
+			_, attempt = easyhttp.AttemptFromContext(req.Context())
+			return do(cli, req)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deadline || !attempt {
+		t.Fatalf("a POST sent once lost its attempt setup, deadline %v, attempt %v", deadline, attempt)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/soyacen/goutils/backoffutils"
+
+	"github.com/soyacen/easyhttp"
 )
 
 var (
@@ -74,12 +76,85 @@ func WithTimeout(timeout time.Duration) Option {
 	}}
 }
 
+// WithServerWait sets whether the wait directed by the server in the Retry-After and rate limit headers
+// is honored, and caps it to max, 0 means no cap. Default is honored, capped to 1 minute.
+func WithServerWait(honor bool, max time.Duration) Option {
+	return Option{applyFunc: func(o *options) {
+		o.honorServerWait = honor
+		o.maxServerWait = max
+	}}
+}
+
+// WithDecide sets a hook deciding the wait before each retry, or giving up.
+func WithDecide(decide DecideFunc) Option {
+	return Option{applyFunc: func(o *options) {
+		o.decide = decide
+	}}
+}
+
+// WithRequestPolicy sets the policy deciding whether a request can be retried at all,
+// default is IsIdempotent, use AnyRequest to retry any request.
+func WithRequestPolicy(policy RetryWithRequest) Option {
+	return Option{applyFunc: func(o *options) {
+		o.shouldRetryWithRequest = policy
+	}}
+}
+
+// WithIdempotencyKey sets an Idempotency-Key header generated by generate on the requests
+// with a non idempotent method and without the header, so they can be retried.
+// generate may be nil, NewIdempotencyKey is used.
+func WithIdempotencyKey(generate func() string) Option {
+	return Option{applyFunc: func(o *options) {
+		if generate == nil {
+			generate = NewIdempotencyKey
+		}
+		o.idempotencyKey = generate
+	}}
+}
+
+// WithBudget sets the retry budget, nil disables it.
+// Default is a budget of 20% of retries plus 10 retries per second over 10 seconds,
+// shared by the calls of the interceptor, so by the calls of a client if it is a client interceptor.
+func WithBudget(budget *Budget) Option {
+	return Option{applyFunc: func(o *options) {
+		o.budget = budget
+	}}
+}
+
+// OnRetryFunc is called before each retry, attempt is the number of the retry about to be sent, starting at 1,
+// reply and err are the result of the previous attempt and wait is the time waited before the retry.
+// ctx is the context of the request given to the interceptor.
+type OnRetryFunc func(ctx context.Context, attempt uint, reply *easyhttp.Reply, err error, wait time.Duration)
+
+// WithOnRetry adds hooks called before each retry.
+func WithOnRetry(hooks ...OnRetryFunc) Option {
+	return Option{applyFunc: func(o *options) {
+		o.onRetry = append(o.onRetry, hooks...)
+	}}
+}
+
+// WithAttemptHeader sets the header carrying the attempt number of the retries, "" disables it.
+// Default is X-Retry.
+func WithAttemptHeader(name string) Option {
+	return Option{applyFunc: func(o *options) {
+		o.attemptHeader = name
+	}}
+}
+
 type options struct {
 	maxAttempts               uint
 	timeout                   time.Duration
 	backoffFunc               backoffutils.BackoffFunc
 	shouldRetryWithError      RetryWithError
 	shouldRetryWithStatusCode RetryWithStatusCode
+	honorServerWait           bool
+	maxServerWait             time.Duration
+	decide                    DecideFunc
+	shouldRetryWithRequest    RetryWithRequest
+	idempotencyKey            func() string
+	budget                    *Budget
+	onRetry                   []OnRetryFunc
+	attemptHeader             string
 }
 
 type Option struct {
@@ -93,6 +168,11 @@ func defaultOptions() *options {
 		shouldRetryWithError:      defaultRetryWithError,
 		shouldRetryWithStatusCode: defaultRetryWithStatusCode,
 		backoffFunc:               BackoffExponentialWithJitter(50*time.Millisecond, 0.10),
+		honorServerWait:           true,
+		maxServerWait:             time.Minute,
+		shouldRetryWithRequest:    IsIdempotent,
+		budget:                    NewBudget(0.2, 10, 10*time.Second),
+		attemptHeader:             "X-Retry",
 	}
 	return o
 }
@@ -0,0 +1,73 @@
+package easyhttpretry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Decision is the wait decided before a retry, given to the DecideFunc.
+type Decision struct {
+	// Attempt is the attempt that just failed, starting at 0
+	Attempt uint
+	Reply   *easyhttp.Reply
+	Err     error
+	// Backoff is the wait computed by the backoff func
+	Backoff time.Duration
+	// ServerWait is the wait directed by the server in the Retry-After or rate limit headers,
+	// capped by the max server wait. It is only meaningful if HasServerWait is true.
+	ServerWait    time.Duration
+	HasServerWait bool
+	// Wait is the wait that will be used, ServerWait if any, Backoff otherwise
+	Wait time.Duration
+}
+
+// DecideFunc decides the wait before a retry, returning false gives up retrying.
+type DecideFunc func(decision Decision) (wait time.Duration, retry bool)
+
+// serverWait parses the wait directed by the server, from the Retry-After header (seconds or HTTP-date),
+// or from the X-RateLimit-Reset / RateLimit-Reset headers when the rate limit is exhausted.
+func serverWait(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	header := response.Header
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return nonNegative(time.Duration(seconds) * time.Second), true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return nonNegative(date.Sub(now)), true
+		}
+	}
+	if response.StatusCode != http.StatusTooManyRequests &&
+		header.Get("X-RateLimit-Remaining") != "0" && header.Get("RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	for _, key := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		value := strings.TrimSpace(header.Get(key))
+		if value == "" {
+			continue
+		}
+		reset, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		// large values are unix timestamps, small ones are delta seconds
+		if reset > 1e9 {
+			return nonNegative(time.Unix(0, int64(reset*float64(time.Second))).Sub(now)), true
+		}
+		return nonNegative(time.Duration(reset * float64(time.Second))), true
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,241 @@
+package easyhttpsingleflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+var errNoResponse = errors.New("easyhttpsingleflight: no response")
+
+// Interceptor coalesces identical in-flight requests: the first request is sent,
+// the identical ones arriving before its reply wait for it and share it.
+//
+// The response body is buffered, each caller reads its own copy.
+// A caller whose context is done stops waiting, the request is canceled once every caller is gone,
+// or at the latest deadline of its callers.
+//
+// The requests are coalesced innermost, see easyhttp.Innermost, so the key is built from the final
+// request, after the per-call path params, query and headers.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	g := &group{o: o, calls: make(map[string]*call)}
+	return easyhttp.Innermost(g.intercept)
+}
+
+type group struct {
+	o     *options
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	// the shared request is canceled at the latest deadline of its callers,
+	// it has none as soon as one of them has none.
+	deadline  time.Time
+	unbounded bool
+	timer     *time.Timer
+
+	response  *http.Response
+	body      []byte
+	err       error
+	statusErr *easyhttp.StatusError
+}
+
+func (g *group) intercept(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+	rawRequest := req.RawRequest()
+	if !g.coalescible(rawRequest) {
+		return do(cli, req)
+	}
+	key := g.key(rawRequest)
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		// the shared request must not be canceled by the caller who happened to come first
+		var ctx context.Context = detached{req.Context()}
+		var cancel context.CancelFunc
+		if g.o.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, g.o.timeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(cli, req.Clone(&shared{Context: ctx, g: g, c: c}), do, key, c)
+	}
+	c.waiters++
+	c.extend(req.Context())
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-req.Context().Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// the next identical request must not join the canceled one
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+			c.cancel()
+		}
+		g.mu.Unlock()
+		return nil, req.Context().Err()
+	}
+	if c.err != nil && c.statusErr == nil {
+		return nil, c.err
+	}
+	// each caller gets its own response and body reader
+	response := *c.response
+	response.Header = c.response.Header.Clone()
+	response.Body = ioutil.NopCloser(bytes.NewReader(c.body))
+	response.Request = rawRequest
+	reply := easyhttp.NewReply(rawRequest, &response)
+	if c.statusErr != nil {
+		// the same bytes as the shared error, peeked from the copy of the caller
+		statusErr := easyhttp.NewStatusError(reply, int64(len(c.statusErr.Body)))
+		statusErr.Payload, statusErr.PayloadErr = c.statusErr.Payload, c.statusErr.PayloadErr
+		return reply, statusErr
+	}
+	return reply, nil
+}
+
+func (g *group) run(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer, key string, c *call) {
+	defer c.cancel()
+	reply, err := do(cli, req)
+	// the reply of a status error is buffered and shared like a successful one
+	var statusErr *easyhttp.StatusError
+	if errors.As(err, &statusErr) && statusErr.Reply() != nil {
+		reply = statusErr.Reply()
+	} else {
+		statusErr = nil
+	}
+	if err == nil && (reply == nil || reply.RawResponse() == nil) {
+		err = errNoResponse
+	}
+	if err == nil || statusErr != nil {
+		c.response = reply.RawResponse()
+		var readErr error
+		if c.body, readErr = reply.Bytes(); readErr != nil {
+			err, statusErr = readErr, nil
+		}
+	} else if reply != nil && reply.RawResponse() != nil && reply.RawResponse().Body != nil {
+		reply.RawResponse().Body.Close()
+	}
+	c.err = err
+	c.statusErr = statusErr
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// extend pushes the deadline of the shared request to the deadline of a new caller, g.mu must be held.
+func (c *call) extend(ctx context.Context) {
+	if c.unbounded {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		c.unbounded = true
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		return
+	}
+	if !deadline.After(c.deadline) {
+		return
+	}
+	c.deadline = deadline
+	if c.timer == nil {
+		c.timer = time.AfterFunc(time.Until(deadline), c.cancel)
+		return
+	}
+	c.timer.Reset(time.Until(deadline))
+}
+
+func (g *group) coalescible(rawRequest *http.Request) bool {
+	method := rawRequest.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	for _, m := range g.o.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *group) key(rawRequest *http.Request) string {
+	var b strings.Builder
+	b.WriteString(rawRequest.Method)
+	b.WriteByte(' ')
+	b.WriteString(rawRequest.URL.String())
+	for _, header := range g.o.headers {
+		b.WriteByte('\n')
+		b.WriteString(header)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(rawRequest.Header.Values(header), ","))
+	}
+	return b.String()
+}
+
+// shared is the context of a shared request, its deadline is the latest deadline of its callers.
+type shared struct {
+	context.Context
+	g *group
+	c *call
+}
+
+func (s *shared) Deadline() (time.Time, bool) {
+	deadline, ok := s.Context.Deadline()
+	s.g.mu.Lock()
+	defer s.g.mu.Unlock()
+	if s.c.unbounded || s.c.deadline.IsZero() {
+		return deadline, ok
+	}
+	if ok && deadline.Before(s.c.deadline) {
+		return deadline, true
+	}
+	return s.c.deadline, true
+}
+
+// detached keeps the values of a context, without its deadline and cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,224 @@
+package easyhttpsingleflight
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestCoalesce(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte("shared"))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor()))
+	// the first caller gives up, the others still get the reply
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := client.Get(ctx, server.URL)
+		canceled <- err
+	}()
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reply, err := client.Get(context.Background(), server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			bodies[i], _ = reply.Text()
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-canceled; err == nil {
+		t.Fatal("expected the canceled caller to fail")
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+	for i, body := range bodies {
+		if body != "shared" {
+			t.Fatalf("caller %d got %q", i, body)
+		}
+	}
+}
+
+func TestAbandoned(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("fresh"))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor()))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for atomic.LoadInt32(&calls) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	if _, err := client.Get(ctx, server.URL); err == nil {
+		t.Fatal("expected the canceled caller to fail")
+	}
+	// the abandoned request is gone, the next caller does not join it
+	reply, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := reply.Text(); body != "fresh" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// the shared request is seen by the interceptors installed after the coalescing
+	var got time.Time
+	var ok bool
+	shared := easyhttp.Innermost(func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		got, ok = req.Context().Deadline()
+		return do(cli, req)
+	})
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor(), shared))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	if _, err := client.Get(ctx, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !got.Equal(want) {
+		t.Fatalf("expected the deadline of the caller %s, got %s, %t", want, got, ok)
+	}
+
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected no deadline for a caller without one")
+	}
+}
+
+func TestPerCallRequest(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor()))
+	var wg sync.WaitGroup
+	for _, who := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(who string) {
+			defer wg.Done()
+			reply, err := client.R().PathParam("id", who).BearerToken(who).Get(context.Background(), server.URL+"/users/:id")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if body, _ := reply.Text(); body != "/users/"+who+" Bearer "+who {
+				t.Errorf("%s got %q", who, body)
+			}
+		}(who)
+	}
+	wg.Wait()
+	if calls != 2 {
+		t.Fatalf("expected a call per caller, got %d", calls)
+	}
+}
+
+func TestCoalesceStatusError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithErrorOnStatus(nil), easyhttp.WithChainInterceptor(Interceptor()))
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply, err := client.Get(context.Background(), server.URL)
+			var statusErr *easyhttp.StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != "missing" {
+				t.Errorf("unexpected error %v", err)
+				return
+			}
+			if reply == nil || statusErr.Reply() != reply {
+				t.Error("expected the reply of the caller")
+				return
+			}
+			if body, _ := reply.Text(); body != "missing" {
+				t.Errorf("unexpected body %q", body)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+}
+
+func TestKey(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	coalesce := Interceptor()
+	var wg sync.WaitGroup
+	for _, token := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			_, err := easyhttp.NewClient().R().BearerToken(token).Use(coalesce).Get(context.Background(), server.URL)
+			if err != nil {
+				t.Error(err)
+			}
+		}(token)
+	}
+	wg.Wait()
+	if calls != 2 {
+		t.Fatalf("expected the requests of different credentials not to be coalesced, got %d calls", calls)
+	}
+}
@@ -0,0 +1,52 @@
+package easyhttpsingleflight
+
+import (
+	"net/http"
+	"time"
+)
+
+type options struct {
+	methods []string
+	headers []string
+	timeout time.Duration
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		methods: []string{http.MethodGet, http.MethodHead},
+		headers: []string{"Accept", "Accept-Encoding", "Authorization", "Cookie"},
+	}
+}
+
+type Option func(o *options)
+
+// WithMethods sets the methods of the requests that can be coalesced, default is GET and HEAD.
+// They must be idempotent.
+func WithMethods(methods ...string) Option {
+	return func(o *options) {
+		o.methods = methods
+	}
+}
+
+// WithHeaders sets the request headers that are part of the key, in addition to the method and url.
+// Default is Accept, Accept-Encoding, Authorization and Cookie, so the responses of different
+// credentials are never shared.
+func WithHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.headers = headers
+	}
+}
+
+// WithTimeout bounds the shared request, whatever the deadlines of its callers. By default it is only
+// bounded by the latest deadline of its callers.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
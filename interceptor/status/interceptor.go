@@ -0,0 +1,57 @@
+package easyhttpstatus
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/soyacen/easyhttp"
+)
+
+var kContentTypeKey = http.CanonicalHeaderKey("Content-Type")
+
+// Interceptor turns replies with an error status code into a *easyhttp.StatusError.
+// The reply is returned along with the error.
+func Interceptor(opts ...Option) easyhttp.Interceptor {
+	o := defaultOptions()
+	o.apply(opts...)
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		reply, err = do(cli, req)
+		if err != nil || reply == nil || reply.RawResponse() == nil {
+			return reply, err
+		}
+		if !o.isError(reply.RawResponse().StatusCode) {
+			return reply, nil
+		}
+		statusErr := easyhttp.NewStatusError(reply, o.maxBodySize)
+		if o.newPayload != nil {
+			statusErr.Payload, statusErr.PayloadErr = decode(reply, o)
+		}
+		return reply, statusErr
+	}
+}
+
+// decode reads the whole body, not only the bytes kept by the StatusError, and decodes it into a new payload.
+// The body stays available through reply.Bytes.
+func decode(reply *easyhttp.Reply, o *options) (interface{}, error) {
+	data, err := reply.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	unmarshal := o.unmarshalFunc
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+		if strings.Contains(reply.RawResponse().Header.Get(kContentTypeKey), "xml") {
+			unmarshal = xml.Unmarshal
+		}
+	}
+	payload := o.newPayload()
+	if err := unmarshal(data, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
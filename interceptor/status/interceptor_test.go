@@ -0,0 +1,106 @@
+package easyhttpstatus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestErrorInto(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"` + r.URL.Query().Get("code") + `","message":"user not found"}`))
+	}))
+	defer server.Close()
+
+	// the client interceptor decodes a fresh payload for each error
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(
+		Interceptor(ErrorInto(func() interface{} { return new(apiError) }))))
+	var payloads []*apiError
+	for _, code := range []string{"not_found", "gone"} {
+		reply, err := client.Get(context.Background(), server.URL+"?code="+code)
+		var statusErr *easyhttp.StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected StatusError, got %v", err)
+		}
+		if statusErr.StatusCode != http.StatusNotFound || statusErr.Reply() != reply {
+			t.Fatalf("unexpected status error %v", statusErr)
+		}
+		apiErr, ok := statusErr.Payload.(*apiError)
+		if !ok || statusErr.PayloadErr != nil || apiErr.Code != code {
+			t.Fatalf("unexpected payload %v %v", statusErr.Payload, statusErr.PayloadErr)
+		}
+		payloads = append(payloads, apiErr)
+	}
+	if payloads[0].Code != "not_found" {
+		t.Fatalf("the first payload is overwritten by %q", payloads[0].Code)
+	}
+}
+
+func TestErrorIntoFullBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"invalid","message":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	reply, err := client.Get(context.Background(), server.URL,
+		Interceptor(WithMaxBodySize(16), ErrorInto(func() interface{} { return new(apiError) })))
+	var statusErr *easyhttp.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if len(statusErr.Body) != 16 {
+		t.Fatalf("got %d bytes of body", len(statusErr.Body))
+	}
+	if apiErr, ok := statusErr.Payload.(*apiError); !ok || apiErr.Code != "invalid" || statusErr.PayloadErr != nil {
+		t.Fatalf("unexpected payload %v %v", statusErr.Payload, statusErr.PayloadErr)
+	}
+	if body, _ := reply.Text(); !strings.HasPrefix(body, `{"code":"invalid"`) {
+		t.Fatalf("the body is consumed, got %q", body)
+	}
+}
+
+func TestErrorIntoDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`<html>bad gateway</html>`))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	_, err := client.Get(context.Background(), server.URL,
+		Interceptor(ErrorInto(func() interface{} { return new(apiError) })))
+	var statusErr *easyhttp.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.Payload != nil || statusErr.PayloadErr == nil {
+		t.Fatalf("the decode error is not reported, payload %v", statusErr.Payload)
+	}
+}
+
+func TestAcceptedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient()
+	_, err := client.Get(context.Background(), server.URL, Interceptor(WithStatusCodes(http.StatusInternalServerError)))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+package easyhttpstatus
+
+import (
+	"github.com/soyacen/easyhttp"
+)
+
+type options struct {
+	isError       func(statusCode int) bool
+	maxBodySize   int64
+	newPayload    func() interface{}
+	unmarshalFunc func(data []byte, v interface{}) error
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		isError:     easyhttp.IsErrorStatus,
+		maxBodySize: easyhttp.DefaultMaxErrorBodySize,
+	}
+}
+
+type Option func(o *options)
+
+// WithStatusCodes sets the status codes treated as errors, any other status code is accepted.
+func WithStatusCodes(codes ...int) Option {
+	return func(o *options) {
+		o.isError = func(statusCode int) bool {
+			for _, code := range codes {
+				if code == statusCode {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// WithIsError sets the function which reports whether a status code is an error.
+// Default is any status code >= 400.
+func WithIsError(isError func(statusCode int) bool) Option {
+	return func(o *options) {
+		o.isError = isError
+	}
+}
+
+// WithMaxBodySize sets the maximum number of bytes of the error body kept in the StatusError,
+// a negative size keeps the whole body. Default is easyhttp.DefaultMaxErrorBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxBodySize = size
+	}
+}
+
+// ErrorInto decodes the whole error body into a value returned by newPayload, which then becomes StatusError.Payload.
+// newPayload is called for each error, e.g. func() interface{} { return new(APIError) }.
+// A failure to read or decode the body is set as StatusError.PayloadErr.
+// Without unmarshalFunc, the body is decoded as XML if the Content-Type says so, otherwise as JSON.
+func ErrorInto(newPayload func() interface{}, unmarshalFunc ...func(data []byte, v interface{}) error) Option {
+	return func(o *options) {
+		o.newPayload = newPayload
+		if len(unmarshalFunc) > 0 {
+			o.unmarshalFunc = unmarshalFunc[0]
+		}
+	}
+}
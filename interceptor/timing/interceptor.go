@@ -0,0 +1,21 @@
+package easyhttptiming
+
+import (
+	"net/http/httptrace"
+
+	"github.com/soyacen/easyhttp"
+)
+
+// Interceptor records the timings of the requests, DNS lookup, connection, TLS handshake,
+// time to first byte and connection reuse, see easyhttp.Reply.Timings.
+func Interceptor() easyhttp.Interceptor {
+	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		timings := easyhttp.NewTimings()
+		req.SetContext(httptrace.WithClientTrace(req.Context(), timings.ClientTrace()))
+		reply, err := do(cli, req)
+		if reply != nil {
+			reply.SetTimings(timings)
+		}
+		return reply, err
+	}
+}
@@ -0,0 +1,41 @@
+package easyhttptiming
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soyacen/easyhttp"
+)
+
+func TestInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("timed"))
+	}))
+	defer server.Close()
+
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(Interceptor()))
+	for i := 0; i < 2; i++ {
+		reply, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reply.Bytes(); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Timings() == nil {
+			t.Fatal("timings are not recorded")
+		}
+		timings := reply.Timings().Snapshot()
+		if timings.TimeToFirstByte() <= 0 || timings.GotConn.IsZero() || timings.WroteRequest.IsZero() {
+			t.Fatalf("request %d, unexpected timings %+v", i, timings)
+		}
+		if reused := i > 0; timings.ConnReused != reused {
+			t.Fatalf("request %d, expected connection reused %v", i, reused)
+		}
+		if i == 0 && timings.Connect() <= 0 {
+			t.Fatal("expected the connection time of a new connection")
+		}
+	}
+}
@@ -2,9 +2,9 @@ package easyhttpurl
 
 import (
 	"net/url"
-	"strings"
 
 	"github.com/soyacen/easyhttp"
+	"github.com/soyacen/easyhttp/internal/urlutils"
 	"github.com/soyacen/goutils/stringutils"
 )
 
@@ -32,41 +32,49 @@ func Host(host string) easyhttp.Interceptor {
 	}
 }
 
-// PathParam replaces one or multiple path param expressions by the given value
+// PathParam replaces one or multiple path param expressions by the given value.
+// The path before the first replacement is kept as the route of the request, see easyhttp.Request.Route.
 func PathParam(key, value string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		if urlutils.HasPathParam(req.RawRequest().URL.Path, key) {
+			setRoute(req)
+		}
 		rawRequest := req.RawRequest()
-		rawRequest.URL.Path = replace(rawRequest.URL.Path, key, value)
+		rawRequest.URL.Path = urlutils.ReplacePathParam(rawRequest.URL.Path, key, value)
 		req.SetRawRequest(rawRequest)
 		return do(cli, req)
 	}
 }
 
-// PathParams replaces one or multiple path param expressions by the given map of key-value pairs
+// PathParams replaces one or multiple path param expressions by the given map of key-value pairs.
+// The path before the first replacement is kept as the route of the request, see easyhttp.Request.Route.
 func PathParams(params map[string]string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
+		for key := range params {
+			if urlutils.HasPathParam(req.RawRequest().URL.Path, key) {
+				setRoute(req)
+				break
+			}
+		}
 		rawRequest := req.RawRequest()
 		for key, value := range params {
-			rawRequest.URL.Path = replace(rawRequest.URL.Path, key, value)
+			rawRequest.URL.Path = urlutils.ReplacePathParam(rawRequest.URL.Path, key, value)
 		}
 		req.SetRawRequest(rawRequest)
 		return do(cli, req)
 	}
 }
 
+// setRoute keeps the current path as the route, unless an outer path param interceptor did already.
+func setRoute(req *easyhttp.Request) {
+	if req.Route() == "" {
+		req.SetRoute(req.RawRequest().URL.Path)
+	}
+}
+
 func QueryParam(key string, values ...string) easyhttp.Interceptor {
 	return func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (reply *easyhttp.Reply, err error) {
-		query := make(url.Values)
-		for _, iv := range values {
-			query.Add(key, iv)
-		}
-		rawRequest := req.RawRequest()
-		reqURL := rawRequest.URL
-		if stringutils.IsBlank(reqURL.RawQuery) {
-			reqURL.RawQuery = query.Encode()
-		} else {
-			reqURL.RawQuery = reqURL.RawQuery + "&" + query.Encode()
-		}
+		urlutils.AddQueryParam(req.RawRequest().URL, key, values...)
 		return do(cli, req)
 	}
 }
@@ -79,17 +87,7 @@ func QueryParams(param map[string][]string) easyhttp.Interceptor {
 				query.Add(k, iv)
 			}
 		}
-		rawRequest := req.RawRequest()
-		reqURL := rawRequest.URL
-		if stringutils.IsBlank(reqURL.RawQuery) {
-			reqURL.RawQuery = query.Encode()
-		} else {
-			reqURL.RawQuery = reqURL.RawQuery + "&" + query.Encode()
-		}
+		urlutils.AddQuery(req.RawRequest().URL, query)
 		return do(cli, req)
 	}
 }
-
-func replace(str, key, value string) string {
-	return strings.Replace(str, ":"+key, value, -1)
-}
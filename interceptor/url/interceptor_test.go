@@ -0,0 +1,113 @@
+package easyhttpurl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soyacen/easyhttp"
+	easyhttpbreaker "github.com/soyacen/easyhttp/interceptor/breaker"
+	easyhttpbulkhead "github.com/soyacen/easyhttp/interceptor/bulkhead"
+	easyhttpratelimit "github.com/soyacen/easyhttp/interceptor/ratelimit"
+	easyhttpretry "github.com/soyacen/easyhttp/interceptor/retry"
+)
+
+func TestPathParamsRoute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var routes []string
+	record := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		routes = append(routes, req.Route())
+		return do(cli, req)
+	}
+	client := easyhttp.NewClient()
+	for _, id := range []string{"1", "2"} {
+		_, err := client.Get(context.Background(), server.URL+"/orgs/:org/users/:id",
+			PathParams(map[string]string{"org": "acme"}),
+			PathParam("id", id),
+			record)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := client.Get(context.Background(), server.URL+"/health", PathParam("id", "1"), record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/orgs/:org/users/:id", "/orgs/:org/users/:id", ""}
+	for i, route := range expected {
+		if routes[i] != route {
+			t.Fatalf("request %d, expected route %q, got %q", i, route, routes[i])
+		}
+	}
+}
+
+func TestClientInterceptorsByRoute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	keys := make(map[string]map[string]bool)
+	record := func(name string, keyFunc func(rawRequest *http.Request) string) func(rawRequest *http.Request) string {
+		keys[name] = make(map[string]bool)
+		return func(rawRequest *http.Request) string {
+			key := keyFunc(rawRequest)
+			mu.Lock()
+			keys[name][key] = true
+			mu.Unlock()
+			return key
+		}
+	}
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(
+		easyhttpbreaker.Interceptor(easyhttpbreaker.WithKey(record("breaker", easyhttpbreaker.ByRoute))),
+		easyhttpratelimit.Interceptor(easyhttpratelimit.WithKey(record("ratelimit", easyhttpratelimit.ByRoute))),
+		easyhttpbulkhead.Interceptor(easyhttpbulkhead.WithKey(record("bulkhead", easyhttpbulkhead.ByRoute))),
+	))
+	for _, id := range []string{"1", "2"} {
+		if _, err := client.Get(context.Background(), server.URL+"/users/:id", PathParam("id", id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, keys := range keys {
+		if len(keys) != 1 {
+			t.Fatalf("%s, expected a single key, got %v", name, keys)
+		}
+	}
+}
+
+func TestRouteOnRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var routes []string
+	record := func(cli *easyhttp.Client, req *easyhttp.Request, do easyhttp.Doer) (*easyhttp.Reply, error) {
+		route, _ := easyhttp.RouteFromContext(req.RawRequest().Context())
+		routes = append(routes, route)
+		return do(cli, req)
+	}
+	noBackoff := func(ctx context.Context, attempt uint) time.Duration { return 0 }
+	client := easyhttp.NewClient(easyhttp.WithChainInterceptor(
+		easyhttpretry.Interceptor(easyhttpretry.WithMaxAttempts(3), easyhttpretry.WithBackoff(noBackoff)),
+	))
+	if _, err := client.Get(context.Background(), server.URL+"/users/:id", PathParam("id", "1"), record); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(routes))
+	}
+	for i, route := range routes {
+		if route != "/users/:id" {
+			t.Fatalf("attempt %d, expected route /users/:id, got %q", i, route)
+		}
+	}
+}
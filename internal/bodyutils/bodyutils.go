@@ -0,0 +1,129 @@
+package bodyutils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var (
+	kContentTypeKey   = http.CanonicalHeaderKey("Content-Type")
+	kContentLengthKey = http.CanonicalHeaderKey("Content-Length")
+)
+
+// SetBytes sets data as the body of rawRequest, GetBody replays the same data.
+func SetBytes(rawRequest *http.Request, data []byte, contentType string) {
+	rawRequest.Body = ioutil.NopCloser(bytes.NewReader(data))
+	rawRequest.ContentLength = int64(len(data))
+	rawRequest.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	rawRequest.Header.Set(kContentTypeKey, contentType)
+	rawRequest.Header.Set(kContentLengthKey, strconv.Itoa(len(data)))
+}
+
+// Marshal returns the body of obj, string and []byte are sent as they are, anything else is marshaled by marshal.
+func Marshal(obj interface{}, marshal func(v interface{}) ([]byte, error)) ([]byte, error) {
+	switch v := obj.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return marshal(obj)
+	}
+}
+
+// SetStream sets body as the body of rawRequest without buffering it.
+// A negative size means the length is unknown and the body is sent chunked.
+// getBody rewinds the body for retries and redirects, it may be nil if the body can not be replayed.
+func SetStream(rawRequest *http.Request, body io.ReadCloser, getBody func() (io.ReadCloser, error), size int64, contentType string) {
+	rawRequest.Body = body
+	rawRequest.GetBody = getBody
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	rawRequest.Header.Set(kContentTypeKey, contentType)
+	if size < 0 {
+		rawRequest.ContentLength = -1
+		rawRequest.Header.Del(kContentLengthKey)
+		return
+	}
+	rawRequest.ContentLength = size
+	rawRequest.Header.Set(kContentLengthKey, strconv.FormatInt(size, 10))
+}
+
+// MakeRewindable buffers the body of rawRequest if it can not be rewound by GetBody,
+// so it can be sent several times. Streaming bodies should come with a GetBody factory instead.
+func MakeRewindable(rawRequest *http.Request) error {
+	if rawRequest.Body == nil || rawRequest.Body == http.NoBody || rawRequest.GetBody != nil {
+		return nil
+	}
+	data, err := ioutil.ReadAll(rawRequest.Body)
+	if err != nil {
+		return err
+	}
+	_ = rawRequest.Body.Close()
+	rawRequest.Body = ioutil.NopCloser(bytes.NewReader(data))
+	rawRequest.ContentLength = int64(len(data))
+	rawRequest.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil
+}
+
+var errBodyClosed = errors.New("bodyutils: read on closed body")
+
+// LazyBody returns a body opened by open on its first Read.
+// A request short-circuited by an interceptor is never sent, its body is then never opened
+// and no file or goroutine is left behind.
+func LazyBody(open func() (io.ReadCloser, error)) io.ReadCloser {
+	return &lazyBody{open: open}
+}
+
+type lazyBody struct {
+	open func() (io.ReadCloser, error)
+
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed bool
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, errBodyClosed
+	}
+	if b.body == nil {
+		body, err := b.open()
+		if err != nil {
+			b.mu.Unlock()
+			return 0, err
+		}
+		b.body = body
+	}
+	body := b.body
+	b.mu.Unlock()
+	return body.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.body == nil {
+		return nil
+	}
+	return b.body.Close()
+}
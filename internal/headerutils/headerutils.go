@@ -0,0 +1,44 @@
+package headerutils
+
+import (
+	"net/http"
+
+	"github.com/soyacen/goutils/stringutils"
+)
+
+const kAuthorizationKey = "Authorization"
+
+// Set sets the header entries associated with key to the single element value.
+func Set(rawRequest *http.Request, key, value string) {
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	rawRequest.Header.Set(key, value)
+}
+
+// Add adds the values to the header entries associated with key.
+func Add(rawRequest *http.Request, key string, values ...string) {
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	for _, value := range values {
+		rawRequest.Header.Add(key, value)
+	}
+}
+
+// SetAuthorization sets the Authorization header to "scheme token", or to token when scheme is blank.
+func SetAuthorization(rawRequest *http.Request, scheme, token string) {
+	if stringutils.IsBlank(scheme) {
+		Set(rawRequest, kAuthorizationKey, token)
+		return
+	}
+	Set(rawRequest, kAuthorizationKey, scheme+" "+token)
+}
+
+// SetBasicAuth sets the Authorization header to use HTTP Basic Authentication.
+func SetBasicAuth(rawRequest *http.Request, username, password string) {
+	if rawRequest.Header == nil {
+		rawRequest.Header = make(http.Header)
+	}
+	rawRequest.SetBasicAuth(username, password)
+}
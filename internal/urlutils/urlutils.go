@@ -0,0 +1,49 @@
+package urlutils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/soyacen/goutils/stringutils"
+)
+
+// ReplacePathParam replaces one or multiple ":key" expressions in path by the given value
+func ReplacePathParam(path, key, value string) string {
+	return strings.Replace(path, ":"+key, value, -1)
+}
+
+// HasPathParam reports whether path contains the ":key" expression
+func HasPathParam(path, key string) bool {
+	return strings.Contains(path, ":"+key)
+}
+
+// HasPathParams reports whether a segment of path is a ":key" expression
+func HasPathParams(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if len(segment) > 1 && segment[0] == ':' {
+			return true
+		}
+	}
+	return false
+}
+
+// AddQuery appends query to the raw query of u, keeping the existing parameters.
+func AddQuery(u *url.URL, query url.Values) {
+	if len(query) == 0 {
+		return
+	}
+	if stringutils.IsBlank(u.RawQuery) {
+		u.RawQuery = query.Encode()
+	} else {
+		u.RawQuery = u.RawQuery + "&" + query.Encode()
+	}
+}
+
+// AddQueryParam appends the values of key to the raw query of u, keeping the existing parameters.
+func AddQueryParam(u *url.URL, key string, values ...string) {
+	query := make(url.Values)
+	for _, value := range values {
+		query.Add(key, value)
+	}
+	AddQuery(u, query)
+}
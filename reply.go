@@ -1,10 +1,62 @@
 package easyhttp
 
-import "net/http"
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// CacheStatus tells how a reply was served by a cache.
+type CacheStatus int
+
+const (
+	// CacheMiss means the reply comes from the server.
+	CacheMiss CacheStatus = iota
+	// CacheHit means the reply is a fresh response served from the cache.
+	CacheHit
+	// CacheRevalidated means the reply is a cached response validated by the server (304 Not Modified).
+	CacheRevalidated
+	// CacheStale means the reply is a stale response served from the cache,
+	// e.g. while revalidating or because the server failed.
+	CacheStale
+)
+
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheHit:
+		return "hit"
+	case CacheRevalidated:
+		return "revalidated"
+	case CacheStale:
+		return "stale"
+	default:
+		return "miss"
+	}
+}
 
 type Reply struct {
 	rawResponse *http.Response
 	rawRequest  *http.Request
+	cacheStatus CacheStatus
+	timings     *Timings
+
+	mu       sync.Mutex
+	body     []byte
+	bodyErr  error
+	bodyRead bool
+}
+
+// NewReply creates a Reply of rawResponse to rawRequest, e.g. to synthesize a reply in an interceptor
+// without sending the request.
+func NewReply(rawRequest *http.Request, rawResponse *http.Response) *Reply {
+	return &Reply{rawRequest: rawRequest, rawResponse: rawResponse}
 }
 
 func (r *Reply) RawResponse() *http.Response {
@@ -14,3 +66,147 @@ func (r *Reply) RawResponse() *http.Response {
 func (r *Reply) RawRequest() *http.Request {
 	return r.rawRequest
 }
+
+// CacheStatus tells whether the reply was served by a cache.
+func (r *Reply) CacheStatus() CacheStatus {
+	return r.cacheStatus
+}
+
+// Timings returns the timings of the request, nil if they are not recorded.
+func (r *Reply) Timings() *Timings {
+	return r.timings
+}
+
+// SetTimings is used by interceptors recording the timings of the request.
+func (r *Reply) SetTimings(timings *Timings) {
+	r.timings = timings
+}
+
+// SetCacheStatus is used by cache interceptors to flag the replies they serve.
+func (r *Reply) SetCacheStatus(status CacheStatus) {
+	r.cacheStatus = status
+}
+
+// Bytes reads the whole response body, closes it and caches the result,
+// so it can be called any number of times.
+// After the first call, RawResponse().Body is replaced by a reader over the cached bytes.
+func (r *Reply) Bytes() ([]byte, error) {
+	return r.readBody()
+}
+
+// Text returns the response body as a string, see Bytes.
+func (r *Reply) Text() (string, error) {
+	data, err := r.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// String returns the response body as a string, it is the same as Text.
+func (r *Reply) String() (string, error) {
+	return r.Text()
+}
+
+// Decode reads the response body and unmarshals it into v with unmarshal.
+// An empty body leaves v untouched.
+func (r *Reply) Decode(v interface{}, unmarshal func(data []byte, v interface{}) error) error {
+	if v == nil {
+		return errors.New("object is nil")
+	}
+	if unmarshal == nil {
+		return errors.New("unmarshal function is nil")
+	}
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return unmarshal(data, v)
+}
+
+// DecodeJSON unmarshals the JSON response body into v.
+func (r *Reply) DecodeJSON(v interface{}) error {
+	return r.Decode(v, json.Unmarshal)
+}
+
+// DecodeXML unmarshals the XML response body into v.
+func (r *Reply) DecodeXML(v interface{}) error {
+	return r.Decode(v, xml.Unmarshal)
+}
+
+// DecodeProto unmarshals the protobuf response body into m.
+func (r *Reply) DecodeProto(m proto.Message) error {
+	return r.Decode(m, UnmarshalProto)
+}
+
+// UnmarshalProto is an unmarshal function for Decode, v must be a proto.Message.
+func UnmarshalProto(data []byte, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("failed convert obj to proto.Message")
+	}
+	return proto.Unmarshal(data, message)
+}
+
+// readBody reads the whole body and closes it.
+func (r *Reply) readBody() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bodyRead {
+		return r.body, r.bodyErr
+	}
+	r.bodyRead = true
+	if r.rawResponse == nil || r.rawResponse.Body == nil || r.rawResponse.Body == http.NoBody {
+		return r.body, r.bodyErr
+	}
+	body := r.rawResponse.Body
+	r.body, r.bodyErr = ioutil.ReadAll(body)
+	if err := body.Close(); err != nil && r.bodyErr == nil {
+		r.bodyErr = err
+	}
+	r.rawResponse.Body = ioutil.NopCloser(bytes.NewReader(r.body))
+	return r.body, r.bodyErr
+}
+
+// peekBody returns at most limit bytes of the body without consuming it, a negative limit means the whole body,
+// the bytes read are put back in front of the rest of the body.
+func (r *Reply) peekBody(limit int64) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bodyRead {
+		if limit >= 0 && int64(len(r.body)) > limit {
+			return r.body[:limit]
+		}
+		return r.body
+	}
+	if r.rawResponse == nil || r.rawResponse.Body == nil || r.rawResponse.Body == http.NoBody {
+		return nil
+	}
+	body := r.rawResponse.Body
+	limited := io.Reader(body)
+	if limit >= 0 {
+		limited = io.LimitReader(body, limit)
+	}
+	peeked, err := ioutil.ReadAll(limited)
+	rest := io.Reader(body)
+	if err != nil {
+		rest = errReader{err: err}
+	}
+	r.rawResponse.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), rest), body}
+	return peeked
+}
+
+// errReader replays the error of a failed peek to the reader of the body.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,115 @@
+package easyhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+)
+
+func TestReplyDecode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"easyhttp"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	reply, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.RawResponse().StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", reply.RawResponse().StatusCode)
+	}
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err := reply.DecodeJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != "easyhttp" {
+		t.Fatalf("unexpected name %q", data.Name)
+	}
+	str, err := reply.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str != `{"name":"easyhttp"}` {
+		t.Fatalf("unexpected body %q", str)
+	}
+	if str, err := reply.String(); err != nil || str != `{"name":"easyhttp"}` {
+		t.Fatalf("unexpected body %q %v", str, err)
+	}
+}
+
+func TestStatusErrorNegativeMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+	defer server.Close()
+
+	reply, err := NewClient().Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a negative size keeps the whole body, before and after the body is read
+	if body := NewStatusError(reply, -1).Body; string(body) != "not found" {
+		t.Fatalf("unexpected error body %q", body)
+	}
+	if _, err := reply.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	if body := NewStatusError(reply, -1).Body; string(body) != "not found" {
+		t.Fatalf("unexpected error body %q", body)
+	}
+}
+
+func TestStatusErrorKeepsBody(t *testing.T) {
+	payload := strings.Repeat("x", DefaultMaxErrorBodySize+1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithErrorOnStatus(nil))
+	reply, err := client.Get(context.Background(), server.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if len(statusErr.Body) != DefaultMaxErrorBodySize {
+		t.Fatalf("unexpected error body size %d", len(statusErr.Body))
+	}
+	body, err := reply.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != payload {
+		t.Fatalf("body is truncated to %d bytes", len(body))
+	}
+}
+
+func TestTimingsSnapshot(t *testing.T) {
+	timings := NewTimings()
+	trace := timings.ClientTrace()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			trace.GotConn(httptrace.GotConnInfo{Reused: true})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		timings.Snapshot()
+	}
+	<-done
+	if snapshot := timings.Snapshot(); !snapshot.ConnReused || snapshot.GotConn.IsZero() {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+}
@@ -8,6 +8,9 @@ import (
 type Request struct {
 	rawRequest *http.Request
 	opts       *executeOptions
+	route      string
+	// innermost are the interceptors installed by Innermost
+	innermost []Interceptor
 }
 
 func (r *Request) Context() context.Context {
@@ -15,14 +18,57 @@ func (r *Request) Context() context.Context {
 }
 
 func (r *Request) SetContext(ctx context.Context) {
-	newRaw := r.rawRequest.WithContext(ctx)
+	newRaw := r.rawRequest.WithContext(r.routeContext(ctx))
 	r.rawRequest = newRaw
 }
 
+// SetRawRequest replaces the raw request, the route of the request is carried by its context.
 func (r *Request) SetRawRequest(rawRequest *http.Request) {
+	if ctx := rawRequest.Context(); r.routeContext(ctx) != ctx {
+		rawRequest = rawRequest.WithContext(r.routeContext(ctx))
+	}
 	r.rawRequest = rawRequest
 }
 
 func (r *Request) RawRequest() *http.Request {
 	return r.rawRequest
 }
+
+// Route returns the route template of the request, e.g. /users/:id, "" if it has none.
+func (r *Request) Route() string {
+	if r.route != "" {
+		return r.route
+	}
+	route, _ := RouteFromContext(r.Context())
+	return route
+}
+
+// SetRoute sets the route template of the request. It is kept by the request, and carried by the context
+// of its raw requests, even the ones set later, so the interceptors reading the raw request only can
+// group the requests by route.
+func (r *Request) SetRoute(route string) {
+	r.route = route
+	r.SetContext(r.Context())
+}
+
+// routeContext returns ctx carrying the route of the request.
+func (r *Request) routeContext(ctx context.Context) context.Context {
+	if r.route == "" {
+		return ctx
+	}
+	if route, ok := RouteFromContext(ctx); ok && route == r.route {
+		return ctx
+	}
+	return ContextWithRoute(ctx, r.route)
+}
+
+// Clone returns a deep copy of r with its context changed to ctx.
+// The body of the raw request is shared, it must be rewound by GetBody before the clone is sent.
+func (r *Request) Clone(ctx context.Context) *Request {
+	return &Request{
+		rawRequest: r.rawRequest.Clone(r.routeContext(ctx)),
+		opts:       r.opts,
+		route:      r.route,
+		innermost:  r.innermost,
+	}
+}
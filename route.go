@@ -0,0 +1,38 @@
+package easyhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeKey struct{}
+
+// ContextWithRoute returns a copy of ctx carrying the route template of a request, e.g. /users/:id.
+func ContextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext returns the route template carried by ctx, false if the request has none.
+// It is set by the path param interceptors, from the path before the params are replaced.
+func RouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeKey{}).(string)
+	return route, ok
+}
+
+// RouteOf returns the route template of rawRequest, "" if it has none.
+// It is the default route of the metric interceptors, the raw path is never used to bound their cardinality.
+func RouteOf(rawRequest *http.Request) string {
+	route, _ := RouteFromContext(rawRequest.Context())
+	return route
+}
+
+// RouteKey returns the method, host and route of rawRequest, e.g. "GET example.com/users/:id",
+// the route is its path if it has no route template. It is the ByRoute key of the breaker,
+// rate limit and bulkhead interceptors.
+func RouteKey(rawRequest *http.Request) string {
+	route, ok := RouteFromContext(rawRequest.Context())
+	if !ok {
+		route = rawRequest.URL.Path
+	}
+	return rawRequest.Method + " " + rawRequest.URL.Host + route
+}
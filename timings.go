@@ -0,0 +1,133 @@
+package easyhttp
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings records where the time of a request went, from the events of net/http/httptrace.
+// Zero times mean the event did not happen, e.g. no DNS lookup or TLS handshake on a reused connection.
+//
+// The hooks may still write the fields after the reply, e.g. the connection of a canceled request,
+// read them from a Snapshot.
+type Timings struct {
+	mu sync.Mutex
+
+	Start                time.Time
+	DNSStart             time.Time
+	DNSDone              time.Time
+	ConnectStart         time.Time
+	ConnectDone          time.Time
+	TLSHandshakeStart    time.Time
+	TLSHandshakeDone     time.Time
+	GotConn              time.Time
+	WroteRequest         time.Time
+	GotFirstResponseByte time.Time
+
+	// ConnReused tells whether the connection was reused from the pool
+	ConnReused bool
+	// ConnWasIdle tells whether the reused connection was idle, for ConnIdleTime
+	ConnWasIdle  bool
+	ConnIdleTime time.Duration
+}
+
+// NewTimings creates Timings of a request starting now.
+func NewTimings() *Timings {
+	return &Timings{Start: time.Now()}
+}
+
+// ClientTrace returns the hooks filling t, to install with httptrace.WithClientTrace.
+func (t *Timings) ClientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.DNSStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.set(&t.DNSDone) },
+		ConnectStart: func(network, addr string) {
+			// with several addresses, the first attempt starts the connection
+			t.mu.Lock()
+			if t.ConnectStart.IsZero() {
+				t.ConnectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.set(&t.ConnectDone)
+			}
+		},
+		TLSHandshakeStart: func() { t.set(&t.TLSHandshakeStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.set(&t.TLSHandshakeDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.GotConn = time.Now()
+			t.ConnReused = info.Reused
+			t.ConnWasIdle = info.WasIdle
+			t.ConnIdleTime = info.IdleTime
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.WroteRequest) },
+		GotFirstResponseByte: func() { t.set(&t.GotFirstResponseByte) },
+	}
+}
+
+// Snapshot returns a copy of t, safe to read while the hooks run.
+func (t *Timings) Snapshot() *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &Timings{
+		Start:                t.Start,
+		DNSStart:             t.DNSStart,
+		DNSDone:              t.DNSDone,
+		ConnectStart:         t.ConnectStart,
+		ConnectDone:          t.ConnectDone,
+		TLSHandshakeStart:    t.TLSHandshakeStart,
+		TLSHandshakeDone:     t.TLSHandshakeDone,
+		GotConn:              t.GotConn,
+		WroteRequest:         t.WroteRequest,
+		GotFirstResponseByte: t.GotFirstResponseByte,
+		ConnReused:           t.ConnReused,
+		ConnWasIdle:          t.ConnWasIdle,
+		ConnIdleTime:         t.ConnIdleTime,
+	}
+}
+
+func (t *Timings) set(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+// DNS returns the duration of the DNS lookup.
+func (t *Timings) DNS() time.Duration {
+	return t.between(&t.DNSStart, &t.DNSDone)
+}
+
+// Connect returns the duration of the TCP connection.
+func (t *Timings) Connect() time.Duration {
+	return t.between(&t.ConnectStart, &t.ConnectDone)
+}
+
+// TLSHandshake returns the duration of the TLS handshake.
+func (t *Timings) TLSHandshake() time.Duration {
+	return t.between(&t.TLSHandshakeStart, &t.TLSHandshakeDone)
+}
+
+// TimeToFirstByte returns the duration from the start of the request to the first byte of the response.
+func (t *Timings) TimeToFirstByte() time.Duration {
+	return t.between(&t.Start, &t.GotFirstResponseByte)
+}
+
+// ServerProcessing returns the duration from the request written to the first byte of the response.
+func (t *Timings) ServerProcessing() time.Duration {
+	return t.between(&t.WroteRequest, &t.GotFirstResponseByte)
+}
+
+func (t *Timings) between(start, end *time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(*start)
+}